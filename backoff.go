@@ -0,0 +1,56 @@
+package pgsql
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff returns the delay before next attempt,
+// attempt is the number of failed attempt starting from 1,
+// and prev is the delay returned for previous attempt
+type Backoff func(attempt int, prev time.Duration) time.Duration
+
+// ConstantBackoff waits d between attempts
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int, time.Duration) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff doubles delay on every attempt starting from base,
+// and limits delay to max if max > 0
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int, _ time.Duration) time.Duration {
+		d := base
+		for i := 1; i < attempt; i++ {
+			if max > 0 && d >= max {
+				break
+			}
+			d *= 2
+		}
+		if max > 0 && d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// DecorrelatedJitterBackoff picks random delay between base and 3 times previous delay,
+// and limits delay to max if max > 0
+//
+// see https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+func DecorrelatedJitterBackoff(base, max time.Duration) Backoff {
+	return func(_ int, prev time.Duration) time.Duration {
+		if prev < base {
+			prev = base
+		}
+		d := base
+		if n := prev*3 - base; n > 0 {
+			d += time.Duration(rand.Int63n(int64(n)))
+		}
+		if max > 0 && d > max {
+			d = max
+		}
+		return d
+	}
+}
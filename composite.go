@@ -0,0 +1,314 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Composite is the value of postgres composite type or row(...) record,
+// it can be used as struct field or array element ([]Composite[T]) with pgctx.Collect.
+//
+// Fields are mapped to T fields by name using DefaultStructMapper
+// when the type is registered by RegisterComposite,
+// otherwise by position in struct field order, ex. row(...) record.
+//
+// Valid is false when NULL.
+type Composite[T any] struct {
+	V     T
+	Valid bool
+}
+
+// NewComposite returns valid Composite of v
+func NewComposite[T any](v T) Composite[T] {
+	return Composite[T]{V: v, Valid: true}
+}
+
+// IsNull implements pgtype.CompositeIndexGetter
+func (c Composite[T]) IsNull() bool {
+	return !c.Valid
+}
+
+// Index implements pgtype.CompositeIndexGetter
+func (c Composite[T]) Index(i int) any {
+	ps, err := compositePositions(reflect.TypeOf(c.V))
+	if err != nil {
+		return compositeErr{err}
+	}
+	if i >= len(ps) {
+		return nil
+	}
+	return compositeIndex(reflect.ValueOf(&c.V).Elem(), ps[i])
+}
+
+// ScanNull implements pgtype.CompositeIndexScanner
+func (c *Composite[T]) ScanNull() error {
+	*c = Composite[T]{}
+	return nil
+}
+
+// ScanIndex implements pgtype.CompositeIndexScanner
+func (c *Composite[T]) ScanIndex(i int) any {
+	if i == 0 {
+		*c = Composite[T]{Valid: true}
+	}
+	ps, err := compositePositions(reflect.TypeOf(c.V))
+	if err != nil {
+		return compositeErr{err}
+	}
+	if i >= len(ps) {
+		return nil
+	}
+	return fieldByIndexAlloc(reflect.ValueOf(&c.V).Elem(), ps[i].index).Addr().Interface()
+}
+
+func (c Composite[T]) indexByName(names []string) pgtype.CompositeIndexGetter {
+	return &compositeByName[T]{c: &c, names: names}
+}
+
+func (c *Composite[T]) scanByName(names []string) pgtype.CompositeIndexScanner {
+	return &compositeByName[T]{c: c, names: names}
+}
+
+// compositeByName maps composite field names to Composite[T] fields
+type compositeByName[T any] struct {
+	c     *Composite[T]
+	names []string
+}
+
+func (n *compositeByName[T]) path(i int) (*fieldPath, error) {
+	if i >= len(n.names) {
+		return nil, nil
+	}
+	fields, err := DefaultStructMapper.fields(reflect.TypeOf(n.c.V))
+	if err != nil {
+		return nil, err
+	}
+	return fields[strings.ToLower(n.names[i])], nil
+}
+
+func (n *compositeByName[T]) IsNull() bool {
+	return !n.c.Valid
+}
+
+func (n *compositeByName[T]) Index(i int) any {
+	p, err := n.path(i)
+	if err != nil {
+		return compositeErr{err}
+	}
+	if p == nil {
+		return nil
+	}
+	return compositeIndex(reflect.ValueOf(&n.c.V).Elem(), p)
+}
+
+func (n *compositeByName[T]) ScanNull() error {
+	return n.c.ScanNull()
+}
+
+func (n *compositeByName[T]) ScanIndex(i int) any {
+	if i == 0 {
+		*n.c = Composite[T]{Valid: true}
+	}
+	p, err := n.path(i)
+	if err != nil {
+		return compositeErr{err}
+	}
+	if p == nil {
+		return nil
+	}
+	return fieldByIndexAlloc(reflect.ValueOf(&n.c.V).Elem(), p.index).Addr().Interface()
+}
+
+// compositeIndex returns field value at p, nil pointer to struct in the path is null
+func compositeIndex(v reflect.Value, p *fieldPath) any {
+	for _, i := range p.index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v.Interface()
+}
+
+// compositeErr reports mapping error from Index and ScanIndex
+type compositeErr struct {
+	err error
+}
+
+func (e compositeErr) Scan(any) error {
+	return e.err
+}
+
+func (e compositeErr) Value() (driver.Value, error) {
+	return nil, e.err
+}
+
+var compositePositionCache sync.Map // map[reflect.Type][]*fieldPath
+
+// compositePositions returns fields of t in struct field order,
+// it walks fields like StructMapper but keeps fields with the same column name
+func compositePositions(t reflect.Type) ([]*fieldPath, error) {
+	if v, ok := compositePositionCache.Load(t); ok {
+		return v.([]*fieldPath), nil
+	}
+
+	ps, err := appendPositions(nil, DefaultStructMapper.tag(), t, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	compositePositionCache.Store(t, ps)
+	return ps, nil
+}
+
+func appendPositions(ps []*fieldPath, tagName string, t reflect.Type, index []int, ptr bool) ([]*fieldPath, error) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		tag, opts, _ := strings.Cut(sf.Tag.Get(tagName), ",")
+		if tag == "-" {
+			continue
+		}
+		idx := append(append([]int{}, index...), i)
+
+		ft := sf.Type
+		isPtr := ft.Kind() == reflect.Pointer
+		if isPtr {
+			ft = ft.Elem()
+		}
+
+		embedded := sf.Anonymous && tag == "" && ft.Kind() == reflect.Struct && (sf.IsExported() || !isPtr)
+		if !embedded && !sf.IsExported() {
+			continue
+		}
+		if embedded || opts == "inline" {
+			if ft.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("pgsql: inline field %s must be struct or pointer to struct", sf.Name)
+			}
+			var err error
+			ps, err = appendPositions(ps, tagName, ft, idx, ptr || isPtr)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ps = append(ps, &fieldPath{index: idx, depth: len(index), ptr: ptr})
+	}
+	return ps, nil
+}
+
+type compositeNameGetter interface {
+	indexByName(names []string) pgtype.CompositeIndexGetter
+}
+
+type compositeNameScanner interface {
+	scanByName(names []string) pgtype.CompositeIndexScanner
+}
+
+// compositeCodec likes pgtype.CompositeCodec but maps Composite fields by name
+type compositeCodec struct {
+	*pgtype.CompositeCodec
+	names []string
+}
+
+func (c *compositeCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	if _, ok := value.(compositeNameGetter); !ok {
+		return c.CompositeCodec.PlanEncode(m, oid, format, value)
+	}
+	next := c.CompositeCodec.PlanEncode(m, oid, format, value)
+	if next == nil {
+		return nil
+	}
+	return &encodePlanCompositeByName{names: c.names, next: next}
+}
+
+func (c *compositeCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	if _, ok := target.(compositeNameScanner); !ok {
+		return c.CompositeCodec.PlanScan(m, oid, format, target)
+	}
+	next := c.CompositeCodec.PlanScan(m, oid, format, target)
+	if next == nil {
+		return nil
+	}
+	return &scanPlanCompositeByName{names: c.names, next: next}
+}
+
+type encodePlanCompositeByName struct {
+	names []string
+	next  pgtype.EncodePlan
+}
+
+func (plan *encodePlanCompositeByName) Encode(value any, buf []byte) ([]byte, error) {
+	return plan.next.Encode(value.(compositeNameGetter).indexByName(plan.names), buf)
+}
+
+type scanPlanCompositeByName struct {
+	names []string
+	next  pgtype.ScanPlan
+}
+
+func (plan *scanPlanCompositeByName) Scan(src []byte, target any) error {
+	return plan.next.Scan(src, target.(compositeNameScanner).scanByName(plan.names))
+}
+
+// RegisterCompositeType registers composite type t and its array type into m,
+// so Composite fields are mapped by name, t.Codec must be *pgtype.CompositeCodec.
+// Array type is not registered if arrayOID is 0.
+func RegisterCompositeType(m *pgtype.Map, t *pgtype.Type, arrayOID uint32) error {
+	cc, ok := t.Codec.(*pgtype.CompositeCodec)
+	if !ok {
+		return fmt.Errorf("pgsql: %s is not composite type", t.Name)
+	}
+
+	names := make([]string, len(cc.Fields))
+	for i, f := range cc.Fields {
+		names[i] = f.Name
+	}
+	ct := &pgtype.Type{
+		Name:  t.Name,
+		OID:   t.OID,
+		Codec: &compositeCodec{CompositeCodec: cc, names: names},
+	}
+	m.RegisterType(ct)
+
+	if arrayOID != 0 {
+		m.RegisterType(&pgtype.Type{
+			Name:  "_" + t.Name,
+			OID:   arrayOID,
+			Codec: &pgtype.ArrayCodec{ElementType: ct},
+		})
+	}
+	return nil
+}
+
+// RegisterComposite loads composite types by name and registers them with their array types into conn,
+// it should be called for each connection, ex. in pgxpool.Config.AfterConnect
+func RegisterComposite(ctx context.Context, conn *pgx.Conn, names ...string) error {
+	for _, name := range names {
+		t, err := conn.LoadType(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		var arrayOID uint32
+		err = conn.QueryRow(ctx, `select typarray from pg_type where oid = $1`, t.OID).Scan(&arrayOID)
+		if err != nil {
+			return err
+		}
+
+		err = RegisterCompositeType(conn.TypeMap(), t, arrayOID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
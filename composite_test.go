@@ -0,0 +1,164 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xkamail/pgsql"
+)
+
+type testAddress struct {
+	City    string
+	ZipCode *string `db:"zip"`
+	Line    string  `db:"-"`
+}
+
+const (
+	testAddressOID      = 100001
+	testAddressArrayOID = 100002
+)
+
+func registerTestAddress(t *testing.T, m *pgtype.Map) {
+	t.Helper()
+
+	text, _ := m.TypeForOID(pgtype.TextOID)
+	// fields are in different order from struct
+	err := pgsql.RegisterCompositeType(m, &pgtype.Type{
+		Name: "address",
+		OID:  testAddressOID,
+		Codec: &pgtype.CompositeCodec{Fields: []pgtype.CompositeCodecField{
+			{Name: "zip", Type: text},
+			{Name: "country", Type: text},
+			{Name: "city", Type: text},
+		}},
+	}, testAddressArrayOID)
+	require.NoError(t, err)
+}
+
+func TestComposite(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID        int64
+		Address   pgsql.Composite[testAddress]
+		Addresses []pgsql.Composite[testAddress]
+	}
+
+	rows := newTestRows([]string{"id", "address", "addresses"}, []uint32{pgtype.Int8OID, testAddressOID, testAddressArrayOID},
+		[]*string{str("1"), str("(10110,th,bangkok)"), str(`{"(,th,bangkok)","(50000,th,chiang mai)"}`)},
+		[]*string{str("2"), nil, str("{}")},
+	)
+	registerTestAddress(t, rows.m)
+
+	xs, err := pgx.CollectRows(rows, pgsql.RowToAddrOfStructByName[user](nil))
+	require.NoError(t, err)
+	assert.Equal(t, []*user{
+		{
+			ID:      1,
+			Address: pgsql.NewComposite(testAddress{City: "bangkok", ZipCode: str("10110")}),
+			Addresses: []pgsql.Composite[testAddress]{
+				pgsql.NewComposite(testAddress{City: "bangkok"}),
+				pgsql.NewComposite(testAddress{City: "chiang mai", ZipCode: str("50000")}),
+			},
+		},
+		{
+			ID:        2,
+			Addresses: []pgsql.Composite[testAddress]{},
+		},
+	}, xs)
+}
+
+func TestComposite_Encode(t *testing.T) {
+	t.Parallel()
+
+	m := pgtype.NewMap()
+	registerTestAddress(t, m)
+
+	v := pgsql.NewComposite(testAddress{City: "bangkok", ZipCode: str("10110"), Line: "ignored"})
+	for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+		b, err := m.Encode(testAddressOID, format, v, nil)
+		require.NoError(t, err)
+
+		var r pgsql.Composite[testAddress]
+		err = m.Scan(testAddressOID, format, b, &r)
+		require.NoError(t, err)
+		assert.Equal(t, pgsql.NewComposite(testAddress{City: "bangkok", ZipCode: str("10110")}), r)
+	}
+
+	b, err := m.Encode(testAddressOID, pgtype.TextFormatCode, v, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "(10110,,bangkok)", string(b))
+
+	b, err = m.Encode(testAddressArrayOID, pgtype.TextFormatCode, []pgsql.Composite[testAddress]{v, {}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"(10110,,bangkok)",NULL}`, string(b))
+
+	b, err = m.Encode(testAddressOID, pgtype.TextFormatCode, pgsql.Composite[testAddress]{}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, b)
+}
+
+func TestComposite_Record(t *testing.T) {
+	t.Parallel()
+
+	m := pgtype.NewMap()
+
+	// row('bangkok', null, 'ignored')
+	builder := pgtype.NewCompositeBinaryBuilder(m, nil)
+	builder.AppendValue(pgtype.TextOID, "bangkok")
+	builder.AppendValue(pgtype.TextOID, nil)
+	builder.AppendValue(pgtype.TextOID, "ignored")
+	b, err := builder.Finish()
+	require.NoError(t, err)
+
+	var r pgsql.Composite[testAddress]
+	err = m.Scan(pgtype.RecordOID, pgtype.BinaryFormatCode, b, &r)
+	require.NoError(t, err)
+	assert.Equal(t, pgsql.NewComposite(testAddress{City: "bangkok"}), r)
+
+	err = m.Scan(pgtype.RecordOID, pgtype.BinaryFormatCode, nil, &r)
+	require.NoError(t, err)
+	assert.False(t, r.Valid)
+}
+
+func TestRegisterCompositeType(t *testing.T) {
+	t.Parallel()
+
+	m := pgtype.NewMap()
+	err := pgsql.RegisterCompositeType(m, &pgtype.Type{Name: "x", OID: 100003, Codec: pgtype.TextCodec{}}, 0)
+	assert.EqualError(t, err, "pgsql: x is not composite type")
+}
+
+func TestComposite_RecordShadowed(t *testing.T) {
+	t.Parallel()
+
+	type base struct {
+		ID      int64
+		Created string
+	}
+	type record struct {
+		base
+		ID   int64
+		Name string
+	}
+
+	m := pgtype.NewMap()
+
+	// row(1, 'c', 2, 'n')
+	builder := pgtype.NewCompositeBinaryBuilder(m, nil)
+	builder.AppendValue(pgtype.Int8OID, int64(1))
+	builder.AppendValue(pgtype.TextOID, "c")
+	builder.AppendValue(pgtype.Int8OID, int64(2))
+	builder.AppendValue(pgtype.TextOID, "n")
+	b, err := builder.Finish()
+	require.NoError(t, err)
+
+	var r pgsql.Composite[record]
+	err = m.Scan(pgtype.RecordOID, pgtype.BinaryFormatCode, b, &r)
+	require.NoError(t, err)
+	assert.Equal(t, pgsql.NewComposite(record{base{1, "c"}, 2, "n"}), r)
+}
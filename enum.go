@@ -0,0 +1,185 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidEnum is the error when value is not in enum labels
+var ErrInvalidEnum = errors.New("pgsql: invalid enum value")
+
+// Enum is the allowed labels of postgres enum type for Go string type E,
+//
+//	type Status string
+//
+//	var StatusEnum = pgsql.NewEnum[Status]("status", "active", "inactive")
+//
+//	func (s *Status) Scan(src any) error { return StatusEnum.Scan(s, src) }
+//	func (s Status) Value() (driver.Value, error) { return StatusEnum.Value(s) }
+type Enum[E ~string] struct {
+	name   string
+	labels []E
+	set    map[E]struct{}
+}
+
+// NewEnum creates and registers enum labels for postgres enum type name,
+// name can be schema qualified.
+// Registered enums are checked by VerifyEnums.
+func NewEnum[E ~string](name string, labels ...E) *Enum[E] {
+	e := &Enum[E]{
+		name:   name,
+		labels: labels,
+		set:    make(map[E]struct{}, len(labels)),
+	}
+	for _, l := range labels {
+		e.set[l] = struct{}{}
+	}
+
+	enumsMu.Lock()
+	enums = append(enums, e)
+	enumsMu.Unlock()
+	return e
+}
+
+// Name returns postgres enum type name
+func (e *Enum[E]) Name() string {
+	return e.name
+}
+
+// Labels returns allowed labels
+func (e *Enum[E]) Labels() []E {
+	return append([]E(nil), e.labels...)
+}
+
+// Valid returns true if v is allowed label
+func (e *Enum[E]) Valid(v E) bool {
+	_, ok := e.set[v]
+	return ok
+}
+
+// Parse returns label s or ErrInvalidEnum
+func (e *Enum[E]) Parse(s string) (E, error) {
+	v := E(s)
+	if !e.Valid(v) {
+		return "", e.invalid(s)
+	}
+	return v, nil
+}
+
+func (e *Enum[E]) invalid(s string) error {
+	labels := make([]string, len(e.labels))
+	for i, l := range e.labels {
+		labels[i] = string(l)
+	}
+	return fmt.Errorf("%w %q for %s (allowed: %s)", ErrInvalidEnum, s, e.name, strings.Join(labels, ", "))
+}
+
+// Scan scans src into dst, null is scanned into empty string
+func (e *Enum[E]) Scan(dst *E, src any) error {
+	var s string
+	switch p := src.(type) {
+	case nil:
+		*dst = ""
+		return nil
+	case string:
+		s = p
+	case []byte:
+		s = string(p)
+	default:
+		return fmt.Errorf("pgsql: cannot scan %T into enum %s", src, e.name)
+	}
+
+	v, err := e.Parse(s)
+	if err != nil {
+		return err
+	}
+	*dst = v
+	return nil
+}
+
+// Value validates v before convert to driver value, empty string is sql null
+func (e *Enum[E]) Value(v E) (driver.Value, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if !e.Valid(v) {
+		return nil, e.invalid(string(v))
+	}
+	return string(v), nil
+}
+
+// Verify checks labels match pg_enum of the connected database
+func (e *Enum[E]) Verify(ctx context.Context, q interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}) error {
+	rows, err := q.Query(ctx, `
+		select e.enumlabel
+		from pg_enum e
+		where e.enumtypid = to_regtype($1)
+		order by e.enumsortorder
+	`, e.name)
+	if err != nil {
+		return err
+	}
+	dbLabels, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+	if len(dbLabels) == 0 {
+		return fmt.Errorf("pgsql: enum %s not found", e.name)
+	}
+
+	dbSet := make(map[string]struct{}, len(dbLabels))
+	var missingGo []string
+	for _, l := range dbLabels {
+		dbSet[l] = struct{}{}
+		if !e.Valid(E(l)) {
+			missingGo = append(missingGo, l)
+		}
+	}
+	var missingDB []string
+	for _, l := range e.labels {
+		if _, ok := dbSet[string(l)]; !ok {
+			missingDB = append(missingDB, string(l))
+		}
+	}
+	if len(missingGo) > 0 || len(missingDB) > 0 {
+		sort.Strings(missingGo)
+		sort.Strings(missingDB)
+		return fmt.Errorf("pgsql: enum %s mismatch, missing in go: %v, missing in database: %v", e.name, missingGo, missingDB)
+	}
+	return nil
+}
+
+type enumVerifier interface {
+	Verify(ctx context.Context, q interface {
+		Query(context.Context, string, ...any) (pgx.Rows, error)
+	}) error
+}
+
+var (
+	enumsMu sync.Mutex
+	enums   []enumVerifier
+)
+
+// VerifyEnums verifies all enums created by NewEnum, it should be called at startup
+func VerifyEnums(ctx context.Context, q interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}) error {
+	enumsMu.Lock()
+	xs := append([]enumVerifier(nil), enums...)
+	enumsMu.Unlock()
+
+	var errs []error
+	for _, e := range xs {
+		errs = append(errs, e.Verify(ctx, q))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,105 @@
+package pgsql_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xkamail/pgsql"
+)
+
+type testStatus string
+
+var testStatusEnum = pgsql.NewEnum[testStatus]("status", "active", "inactive")
+
+func (s *testStatus) Scan(src any) error          { return testStatusEnum.Scan(s, src) }
+func (s testStatus) Value() (driver.Value, error) { return testStatusEnum.Value(s) }
+
+func TestEnum(t *testing.T) {
+	t.Parallel()
+
+	var s testStatus
+	assert.NoError(t, s.Scan("active"))
+	assert.Equal(t, testStatus("active"), s)
+	assert.NoError(t, s.Scan([]byte("inactive")))
+	assert.Equal(t, testStatus("inactive"), s)
+	assert.NoError(t, s.Scan(nil))
+	assert.Equal(t, testStatus(""), s)
+
+	err := s.Scan("deleted")
+	assert.ErrorIs(t, err, pgsql.ErrInvalidEnum)
+	assert.EqualError(t, err, `pgsql: invalid enum value "deleted" for status (allowed: active, inactive)`)
+	assert.Error(t, s.Scan(1))
+
+	v, err := testStatus("active").Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "active", v)
+	v, err = testStatus("").Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+	_, err = testStatus("deleted").Value()
+	assert.ErrorIs(t, err, pgsql.ErrInvalidEnum)
+
+	_, err = testStatusEnum.Parse("deleted")
+	assert.ErrorIs(t, err, pgsql.ErrInvalidEnum)
+	assert.Equal(t, "status", testStatusEnum.Name())
+	assert.Equal(t, []testStatus{"active", "inactive"}, testStatusEnum.Labels())
+}
+
+func TestEnum_Collect(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID     int64
+		Status testStatus
+	}
+
+	// unregistered enum oid
+	const statusOID = 100000
+	rows := newTestRows([]string{"id", "status"}, []uint32{pgtype.Int8OID, statusOID},
+		[]*string{str("1"), str("active")},
+	)
+	xs, err := pgx.CollectRows(rows, pgsql.RowToAddrOfStructByName[user](nil))
+	require.NoError(t, err)
+	assert.Equal(t, []*user{{1, "active"}}, xs)
+
+	rows = newTestRows([]string{"id", "status"}, []uint32{pgtype.Int8OID, statusOID},
+		[]*string{str("1"), str("deleted")},
+	)
+	_, err = pgx.CollectRows(rows, pgsql.RowToAddrOfStructByName[user](nil))
+	assert.ErrorIs(t, err, pgsql.ErrInvalidEnum)
+}
+
+func TestEnum_Verify(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	ctx := context.Background()
+
+	mock.ExpectQuery("select e.enumlabel").
+		WithArgs("status").
+		WillReturnRows(pgxmock.NewRows([]string{"enumlabel"}).AddRow("active").AddRow("inactive"))
+	assert.NoError(t, testStatusEnum.Verify(ctx, mock))
+
+	mock.ExpectQuery("select e.enumlabel").
+		WithArgs("status").
+		WillReturnRows(pgxmock.NewRows([]string{"enumlabel"}).AddRow("active").AddRow("banned"))
+	assert.EqualError(t, pgsql.VerifyEnums(ctx, mock),
+		"pgsql: enum status mismatch, missing in go: [banned], missing in database: [inactive]")
+
+	mock.ExpectQuery("select e.enumlabel").
+		WithArgs("status").
+		WillReturnRows(pgxmock.NewRows([]string{"enumlabel"}))
+	assert.EqualError(t, testStatusEnum.Verify(ctx, mock), "pgsql: enum status not found")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,117 @@
+package pgsql
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrorMapper maps postgres errors into domain errors
+type ErrorMapper struct {
+	mu    sync.RWMutex
+	rules []errorRule
+}
+
+type errorRule struct {
+	code       string
+	constraint string
+	target     error
+}
+
+// NewErrorMapper creates new error mapper
+func NewErrorMapper() *ErrorMapper {
+	return &ErrorMapper{}
+}
+
+// DefaultErrorMapper is the error mapper used by RunInTx and pgctx
+var DefaultErrorMapper = NewErrorMapper()
+
+// Register maps postgres error with given code and constraint into target,
+// constraint can be empty to match any constraint.
+//
+// Rule with constraint takes precedence over rule without constraint,
+// registering the same code and constraint again replaces the target.
+func (m *ErrorMapper) Register(code, constraint string, target error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, r := range m.rules {
+		if r.code == code && r.constraint == constraint {
+			m.rules[i].target = target
+			return
+		}
+	}
+	m.rules = append(m.rules, errorRule{
+		code:       code,
+		constraint: constraint,
+		target:     target,
+	})
+}
+
+// Map wraps err with registered target error,
+// the result matches both target and err with errors.Is and errors.As.
+//
+// Map returns err if err is not a postgres error,
+// already mapped, or no rule matched.
+func (m *ErrorMapper) Map(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var mErr *mappedError
+	if errors.As(err, &mErr) {
+		return err
+	}
+
+	e, ok := AsError(err)
+	if !ok {
+		return err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var target error
+	for _, r := range m.rules {
+		if r.code != e.Code {
+			continue
+		}
+		if r.constraint == e.Constraint {
+			target = r.target
+			break
+		}
+		if r.constraint == "" && target == nil {
+			target = r.target
+		}
+	}
+	if target == nil {
+		return err
+	}
+	return &mappedError{
+		target: target,
+		err:    err,
+	}
+}
+
+// RegisterError registers error mapping to DefaultErrorMapper
+func RegisterError(code, constraint string, target error) {
+	DefaultErrorMapper.Register(code, constraint, target)
+}
+
+// MapError maps err using DefaultErrorMapper
+func MapError(err error) error {
+	return DefaultErrorMapper.Map(err)
+}
+
+type mappedError struct {
+	target error
+	err    error
+}
+
+func (e *mappedError) Error() string {
+	return fmt.Sprintf("%v: %v", e.target, e.err)
+}
+
+func (e *mappedError) Unwrap() []error {
+	return []error{e.target, e.err}
+}
@@ -0,0 +1,91 @@
+package pgsql_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xkamail/pgsql"
+	"github.com/xkamail/pgsql/pgcode"
+)
+
+func TestErrorMapper(t *testing.T) {
+	t.Parallel()
+
+	errEmailTaken := errors.New("email taken")
+	errDuplicate := errors.New("duplicate")
+
+	m := pgsql.NewErrorMapper()
+	m.Register(pgcode.UniqueViolation, "", errDuplicate)
+	m.Register(pgcode.UniqueViolation, "users_email_key", errEmailTaken)
+
+	t.Run("Constraint", func(t *testing.T) {
+		pgErr := &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}
+		err := m.Map(fmt.Errorf("insert: %w", pgErr))
+		assert.ErrorIs(t, err, errEmailTaken)
+		assert.NotErrorIs(t, err, errDuplicate)
+		assert.ErrorIs(t, err, pgErr)
+		assert.True(t, pgsql.IsUniqueViolation(err, "users_email_key"))
+
+		var e *pgconn.PgError
+		assert.True(t, errors.As(err, &e))
+	})
+
+	t.Run("Any Constraint", func(t *testing.T) {
+		err := m.Map(&pgconn.PgError{Code: "23505", ConstraintName: "users_pkey"})
+		assert.ErrorIs(t, err, errDuplicate)
+	})
+
+	t.Run("Not Matched", func(t *testing.T) {
+		pgErr := &pgconn.PgError{Code: "23503"}
+		assert.Equal(t, error(pgErr), m.Map(pgErr))
+
+		err := errors.New("error")
+		assert.Equal(t, err, m.Map(err))
+		assert.Nil(t, m.Map(nil))
+	})
+
+	t.Run("Already Mapped", func(t *testing.T) {
+		err := m.Map(&pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"})
+		assert.Equal(t, err, m.Map(err))
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		errConflict := errors.New("conflict")
+
+		m := pgsql.NewErrorMapper()
+		m.Register(pgcode.UniqueViolation, "users_email_key", errDuplicate)
+		m.Register(pgcode.UniqueViolation, "users_email_key", errConflict)
+
+		err := m.Map(&pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"})
+		assert.ErrorIs(t, err, errConflict)
+		assert.NotErrorIs(t, err, errDuplicate)
+	})
+}
+
+var errRunInTxTaken = errors.New("test_run_in_tx_map_error taken")
+
+func init() {
+	pgsql.RegisterError(pgcode.UniqueViolation, "test_run_in_tx_map_error_key", errRunInTxTaken)
+}
+
+func TestRunInTxMapError(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err = pgsql.RunInTx(mock, nil, func(tx pgx.Tx) error {
+		return &pgconn.PgError{Code: "23505", ConstraintName: "test_run_in_tx_map_error_key"}
+	})
+	assert.ErrorIs(t, err, errRunInTxTaken)
+}
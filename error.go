@@ -1,11 +1,13 @@
 package pgsql
 
 import (
-	"context"
 	"errors"
 	"regexp"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/lib/pq"
+
+	"github.com/xkamail/pgsql/pgcode"
 )
 
 func contains(xs []string, x string) bool {
@@ -17,82 +19,205 @@ func contains(xs []string, x string) bool {
 	return false
 }
 
-// IsErrorCode checks is error has given code
-func IsErrorCode(err error, code string) bool {
+// Error is the driver-neutral postgres error
+// converted from *pgconn.PgError or *pq.Error
+type Error struct {
+	Severity string
+	Code     string
+	Message  string
+	Detail   string
+	Hint     string
+	Schema   string
+	Table    string
+	Column   string
+	DataType string
+
+	// Constraint is the violated constraint name,
+	// or extracted from message if driver does not provide it
+	Constraint string
+
+	err error
+}
+
+// Error returns the driver error message,
+// or formats like *pgconn.PgError if e is not converted from driver error
+func (e *Error) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return e.Severity + ": " + e.Message + " (SQLSTATE " + e.Code + ")"
+}
+
+// Unwrap returns the original driver error
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Class returns error class, which is the first 2 characters of code
+func (e *Error) Class() string {
+	return pgcode.Class(e.Code)
+}
+
+// Name returns error condition name, ex. unique_violation
+func (e *Error) Name() string {
+	return pgcode.Name(e.Code)
+}
+
+// AsError finds the first postgres error in err's chain and converts it into *Error
+func AsError(err error) (*Error, bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		e = &Error{
+			Severity:   pgErr.Severity,
+			Code:       pgErr.Code,
+			Message:    pgErr.Message,
+			Detail:     pgErr.Detail,
+			Hint:       pgErr.Hint,
+			Schema:     pgErr.SchemaName,
+			Table:      pgErr.TableName,
+			Column:     pgErr.ColumnName,
+			DataType:   pgErr.DataTypeName,
+			Constraint: pgErr.ConstraintName,
+			err:        pgErr,
+		}
+	}
+
 	var pqErr *pq.Error
-	if errors.As(err, &pqErr) && string(pqErr.Code) == code {
-		return true
+	if e == nil && errors.As(err, &pqErr) {
+		e = &Error{
+			Severity:   pqErr.Severity,
+			Code:       string(pqErr.Code),
+			Message:    pqErr.Message,
+			Detail:     pqErr.Detail,
+			Hint:       pqErr.Hint,
+			Schema:     pqErr.Schema,
+			Table:      pqErr.Table,
+			Column:     pqErr.Column,
+			DataType:   pqErr.DataTypeName,
+			Constraint: pqErr.Constraint,
+			err:        pqErr,
+		}
 	}
-	return false
+
+	if e == nil {
+		return nil, false
+	}
+	if e.Constraint == "" {
+		e.Constraint = extractConstraint(e.Message)
+	}
+	return e, true
+}
+
+// IsErrorCode checks is error has given code
+func IsErrorCode(err error, code string) bool {
+	e, ok := AsError(err)
+	return ok && e.Code == code
 }
 
 // IsErrorClass checks is error has given class
 func IsErrorClass(err error, class string) bool {
-	var pqErr *pq.Error
-	if errors.As(err, &pqErr) && string(pqErr.Code.Class()) == class {
+	e, ok := AsError(err)
+	return ok && e.Class() == class
+}
+
+func isConstraintViolation(err error, code string, constraint []string) bool {
+	e, ok := AsError(err)
+	if !ok || e.Code != code {
+		return false
+	}
+	if len(constraint) == 0 {
 		return true
 	}
-	return false
+	return contains(constraint, e.Constraint)
 }
 
 // IsUniqueViolation checks is error an unique_violation with given constraint,
 // constraint can be empty to ignore constraint name checks
 func IsUniqueViolation(err error, constraint ...string) bool {
-	var pqErr *pq.Error
-	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
-		if len(constraint) == 0 {
-			return true
-		}
-		return contains(constraint, extractConstraint(pqErr))
+	return isConstraintViolation(err, pgcode.UniqueViolation, constraint)
+}
+
+// IsForeignKeyViolation checks is error an foreign_key_violation
+func IsForeignKeyViolation(err error, constraint ...string) bool {
+	return isConstraintViolation(err, pgcode.ForeignKeyViolation, constraint)
+}
+
+// IsCheckViolation checks is error an check_violation with given constraint,
+// constraint can be empty to ignore constraint name checks
+func IsCheckViolation(err error, constraint ...string) bool {
+	return isConstraintViolation(err, pgcode.CheckViolation, constraint)
+}
+
+// IsExclusionViolation checks is error an exclusion_violation with given constraint,
+// constraint can be empty to ignore constraint name checks
+func IsExclusionViolation(err error, constraint ...string) bool {
+	return isConstraintViolation(err, pgcode.ExclusionViolation, constraint)
+}
+
+// IsNotNullViolation checks is error an not_null_violation on given column,
+// column can be empty to ignore column name checks
+func IsNotNullViolation(err error, column ...string) bool {
+	e, ok := AsError(err)
+	if !ok || e.Code != pgcode.NotNullViolation {
+		return false
 	}
-	return false
+	if len(column) == 0 {
+		return true
+	}
+	return contains(column, e.Column)
 }
 
 // IsInvalidTextRepresentation checks is error an invalid_text_representation
 func IsInvalidTextRepresentation(err error) bool {
-	return IsErrorCode(err, "22P02")
+	return IsErrorCode(err, pgcode.InvalidTextRepresentation)
 }
 
 // IsCharacterNotInRepertoire checks is error an character_not_in_repertoire
 func IsCharacterNotInRepertoire(err error) bool {
-	return IsErrorCode(err, "22021")
-}
-
-// IsForeignKeyViolation checks is error an foreign_key_violation
-func IsForeignKeyViolation(err error, constraint ...string) bool {
-	var pqErr *pq.Error
-	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
-		if len(constraint) == 0 {
-			return true
-		}
-		return contains(constraint, extractConstraint(pqErr))
-	}
-	return false
+	return IsErrorCode(err, pgcode.CharacterNotInRepertoire)
 }
 
 // IsQueryCanceled checks is error an query_canceled error
 // (pq: canceling statement due to user request)
 func IsQueryCanceled(err error) bool {
-	return IsErrorCode(err, "57014")
+	return IsErrorCode(err, pgcode.QueryCanceled)
 }
 
 // IsSerializationFailure checks is error an serialization_failure error
 // (pq: could not serialize access due to read/write dependencies among transactions)
 func IsSerializationFailure(err error) bool {
-	return IsErrorCode(err, "40001")
+	return IsErrorCode(err, pgcode.SerializationFailure)
 }
 
-func extractConstraint(err *pq.Error) string {
-	if err.Constraint != "" {
-		return err.Constraint
-	}
-	if err.Message == "" {
+// IsDeadlock checks is error an deadlock_detected error
+// (pq: deadlock detected)
+func IsDeadlock(err error) bool {
+	return IsErrorCode(err, pgcode.DeadlockDetected)
+}
+
+// IsLockNotAvailable checks is error an lock_not_available error
+// (pq: could not obtain lock on row in relation)
+func IsLockNotAvailable(err error) bool {
+	return IsErrorCode(err, pgcode.LockNotAvailable)
+}
+
+func extractConstraint(msg string) string {
+	if msg == "" {
 		return ""
 	}
-	if s := extractCRDBKey(err.Message); s != "" {
+	if s := extractCRDBKey(msg); s != "" {
 		return s
 	}
-	if s := extractLastQuote(err.Message); s != "" {
+	if s := extractLastQuote(msg); s != "" {
 		return s
 	}
 	return ""
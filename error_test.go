@@ -2,13 +2,16 @@ package pgsql_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/xkamail/pgsql"
+	"github.com/xkamail/pgsql/pgcode"
 )
 
 func TestIsUniqueViolation(t *testing.T) {
@@ -83,6 +86,109 @@ func TestIsForeignKeyViolation(t *testing.T) {
 	}, "pkey", "b_a_id_fkey", "a@primary"))
 }
 
+func TestPgError(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, pgsql.IsUniqueViolation(&pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23505",
+		TableName:      "users",
+		ConstraintName: "users_email_key",
+	}, "users_email_key"))
+
+	assert.False(t, pgsql.IsUniqueViolation(&pgconn.PgError{
+		Code:           "23505",
+		ConstraintName: "users_email_key",
+	}, "pkey"))
+
+	assert.True(t, pgsql.IsForeignKeyViolation(&pgconn.PgError{
+		Code:    "23503",
+		Message: `insert or update on table "b" violates foreign key constraint "b_a_id_fkey"`,
+	}, "b_a_id_fkey"))
+
+	assert.True(t, pgsql.IsSerializationFailure(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, pgsql.IsErrorClass(&pgconn.PgError{Code: "40P01"}, "40"))
+	assert.False(t, pgsql.IsErrorClass(&pgconn.PgError{Code: "23505"}, "40"))
+
+	t.Run("Wrapped", func(t *testing.T) {
+		err := fmt.Errorf("create user: %w", &pgconn.PgError{
+			Code:           "23505",
+			ConstraintName: "users_email_key",
+		})
+		assert.True(t, pgsql.IsUniqueViolation(err, "users_email_key"))
+
+		err = fmt.Errorf("create user: %w", &pq.Error{
+			Code:       "23505",
+			Constraint: "users_email_key",
+		})
+		assert.True(t, pgsql.IsUniqueViolation(err, "users_email_key"))
+	})
+
+	assert.False(t, pgsql.IsErrorCode(nil, ""))
+	assert.False(t, pgsql.IsErrorCode(fmt.Errorf("error"), ""))
+}
+
+func TestAsError(t *testing.T) {
+	t.Parallel()
+
+	pgErr := &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23514",
+		Message:        `new row for relation "users" violates check constraint "users_age_check"`,
+		Detail:         "Failing row contains (1, -1).",
+		SchemaName:     "public",
+		TableName:      "users",
+		ConstraintName: "users_age_check",
+	}
+	e, ok := pgsql.AsError(fmt.Errorf("insert: %w", pgErr))
+	if assert.True(t, ok) {
+		assert.Equal(t, pgcode.CheckViolation, e.Code)
+		assert.Equal(t, pgcode.ClassIntegrityConstraintViolation, e.Class())
+		assert.Equal(t, "check_violation", e.Name())
+		assert.Equal(t, "users", e.Table)
+		assert.Equal(t, "public", e.Schema)
+		assert.Equal(t, "users_age_check", e.Constraint)
+		assert.Equal(t, "Failing row contains (1, -1).", e.Detail)
+		assert.Equal(t, pgErr.Error(), e.Error())
+		assert.ErrorIs(t, e, pgErr)
+	}
+
+	e, ok = pgsql.AsError(&pq.Error{
+		Code:    "23503",
+		Message: `insert or update on table "b" violates foreign key constraint "b_a_id_fkey"`,
+		Column:  "a_id",
+		Hint:    "hint",
+	})
+	if assert.True(t, ok) {
+		assert.Equal(t, pgcode.ForeignKeyViolation, e.Code)
+		assert.Equal(t, "b_a_id_fkey", e.Constraint)
+		assert.Equal(t, "a_id", e.Column)
+		assert.Equal(t, "hint", e.Hint)
+	}
+
+	_, ok = pgsql.AsError(fmt.Errorf("error"))
+	assert.False(t, ok)
+
+	e = &pgsql.Error{Severity: "ERROR", Code: "23505", Message: "duplicate key"}
+	assert.Equal(t, "ERROR: duplicate key (SQLSTATE 23505)", e.Error())
+	assert.Nil(t, e.Unwrap())
+	assert.Equal(t, "insert: ERROR: duplicate key (SQLSTATE 23505)", fmt.Errorf("insert: %w", e).Error())
+}
+
+func TestErrorHelpers(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, pgsql.IsCheckViolation(&pgconn.PgError{Code: "23514", ConstraintName: "age_check"}, "age_check"))
+	assert.False(t, pgsql.IsCheckViolation(&pgconn.PgError{Code: "23514", ConstraintName: "age_check"}, "name_check"))
+	assert.True(t, pgsql.IsNotNullViolation(&pgconn.PgError{Code: "23502", ColumnName: "name"}))
+	assert.True(t, pgsql.IsNotNullViolation(&pgconn.PgError{Code: "23502", ColumnName: "name"}, "name"))
+	assert.False(t, pgsql.IsNotNullViolation(&pgconn.PgError{Code: "23502", ColumnName: "name"}, "email"))
+	assert.True(t, pgsql.IsExclusionViolation(&pgconn.PgError{Code: "23P01", ConstraintName: "no_overlap"}, "no_overlap"))
+	assert.True(t, pgsql.IsLockNotAvailable(&pgconn.PgError{Code: "55P03"}))
+	assert.True(t, pgsql.IsDeadlock(&pq.Error{Code: "40P01"}))
+	assert.False(t, pgsql.IsDeadlock(&pq.Error{Code: "40001"}))
+}
+
 func TestIsQueryCanceled(t *testing.T) {
 	t.Parallel()
 
@@ -1,6 +1,6 @@
 module github.com/xkamail/pgsql
 
-go 1.20
+go 1.23
 
 require (
 	github.com/jackc/pgx/v5 v5.3.1
@@ -27,21 +27,19 @@ func TestNullInt64(t *testing.T) {
 	if !assert.NoError(t, err) {
 		return
 	}
-	defer db.Exec(`drop table test_pgsql_null_int64`)
+	defer db.Exec(context.Background(), `drop table test_pgsql_null_int64`)
 
 	t.Run("Scan", func(t *testing.T) {
 		{
-			ctx := context.Background()
 			var p int64
-			err = db.QueryRow(`select value from test_pgsql_null_int64 where id = 0`).Scan(pgsql.NullInt64(&p))
+			err = db.QueryRow(context.Background(), `select value from test_pgsql_null_int64 where id = 0`).Scan(pgsql.NullInt64(&p))
 			assert.NoError(t, err)
 			assert.Equal(t, int64(1), p)
 		}
 
 		{
-			ctx := context.Background()
 			var p int64
-			err = db.QueryRow(`select value from test_pgsql_null_int64 where id = 1`).Scan(pgsql.NullInt64(&p))
+			err = db.QueryRow(context.Background(), `select value from test_pgsql_null_int64 where id = 1`).Scan(pgsql.NullInt64(&p))
 			assert.NoError(t, err)
 			assert.Equal(t, int64(0), p)
 		}
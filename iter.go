@@ -32,7 +32,7 @@ func IterContext(ctx context.Context, q interface {
 	defer rows.Close()
 
 	for rows.Next() {
-		err := iter(Scan(rows.Scan))
+		err := iter(ScanPgx(rows.Scan))
 		if err != nil {
 			return err
 		}
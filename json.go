@@ -31,11 +31,19 @@ func (v *jsonValue) Scan(src any) error {
 	case string:
 		b = []byte(p)
 	default:
-		return fmt.Errorf("pgsql: JSON not support scan source")
+		return fmt.Errorf("pgsql: JSON not support scan source %T", src)
 	}
 	return json.Unmarshal(b, v.value)
 }
 
+// ScanBytes implements pgtype.BytesScanner for pgx json and jsonb codecs
+func (v *jsonValue) ScanBytes(src []byte) error {
+	if src == nil {
+		return nil
+	}
+	return json.Unmarshal(src, v.value)
+}
+
 func (v *jsonValue) Value() (driver.Value, error) {
 	return json.Marshal(v.value)
 }
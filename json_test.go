@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/xkamail/pgsql"
 )
 
@@ -54,6 +56,6 @@ func TestJSON(t *testing.T) {
 
 	obj.A = ""
 	obj.B = 0
-	err = db.QueryRow(`select null`).Scan(pgsql.JSON(&obj))
+	err = db.QueryRow(context.Background(), `select null`).Scan(pgsql.JSON(&obj))
 	assert.NoError(t, err)
 }
@@ -0,0 +1,165 @@
+package pgsql
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrJSONNull is the error when scan NULL into JSONB with JSONNullError
+var ErrJSONNull = errors.New("pgsql: cannot scan NULL into JSONB")
+
+// JSONNull is the behavior when scan NULL into JSONB
+type JSONNull int
+
+const (
+	// JSONNullZero sets V to zero value, JSONB[*T] gets nil pointer
+	JSONNullZero JSONNull = iota
+
+	// JSONNullError returns ErrJSONNull
+	JSONNullError
+)
+
+// JSONOptions is the JSONB options
+type JSONOptions struct {
+	// Null is the behavior when scan NULL
+	Null JSONNull
+
+	// DisallowUnknownFields returns error when decode unknown object key,
+	// it does not apply to custom Unmarshal
+	DisallowUnknownFields bool
+
+	// Marshal encodes the value, default is json.Marshal
+	Marshal func(v any) ([]byte, error)
+
+	// Unmarshal decodes the value, default is json.Unmarshal
+	Unmarshal func(data []byte, v any) error
+}
+
+var (
+	defaultJSONOptions JSONOptions
+	jsonOptions        sync.Map // map[reflect.Type]*JSONOptions
+)
+
+// RegisterJSONOptions sets the options of JSONB[T], JSONB of unregistered type uses zero JSONOptions.
+// Options are looked up by T, so JSONB[*T] needs RegisterJSONOptions[*T].
+// It should be called at init before JSONB[T] is used.
+func RegisterJSONOptions[T any](opts JSONOptions) {
+	jsonOptions.Store(reflect.TypeFor[T](), &opts)
+}
+
+func jsonOptionsOf[T any]() *JSONOptions {
+	if v, ok := jsonOptions.Load(reflect.TypeFor[T]()); ok {
+		return v.(*JSONOptions)
+	}
+	return &defaultJSONOptions
+}
+
+func (opts *JSONOptions) marshal(v any) ([]byte, error) {
+	if opts.Marshal != nil {
+		return opts.Marshal(v)
+	}
+	return json.Marshal(v)
+}
+
+func (opts *JSONOptions) unmarshal(data []byte, v any) error {
+	if opts.Unmarshal != nil {
+		return opts.Unmarshal(data, v)
+	}
+	if !opts.DisallowUnknownFields {
+		return json.Unmarshal(data, v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// JSONB is the typed json value for json and jsonb column,
+// it can be used as struct field with pgctx.Collect.
+//
+// Valid is false when NULL, and Value encodes invalid JSONB as NULL.
+// Use JSONB[*T] to scan NULL into nil pointer with the default JSONNullZero.
+//
+// Options are set per T by RegisterJSONOptions.
+type JSONB[T any] struct {
+	V     T
+	Valid bool
+}
+
+// NewJSONB returns valid JSONB of v
+func NewJSONB[T any](v T) JSONB[T] {
+	return JSONB[T]{V: v, Valid: true}
+}
+
+// Scan implements sql.Scanner
+func (j *JSONB[T]) Scan(src any) error {
+	switch p := src.(type) {
+	case nil:
+		return j.ScanBytes(nil)
+	case []byte:
+		return j.ScanBytes(p)
+	case string:
+		return j.ScanBytes([]byte(p))
+	}
+	return fmt.Errorf("pgsql: cannot scan %T into %T", src, j)
+}
+
+// ScanBytes implements pgtype.BytesScanner,
+// pgx strips jsonb version before calling it
+func (j *JSONB[T]) ScanBytes(src []byte) error {
+	opts := jsonOptionsOf[T]()
+	if src == nil {
+		if opts.Null == JSONNullError {
+			return ErrJSONNull
+		}
+		var zero T
+		j.V, j.Valid = zero, false
+		return nil
+	}
+
+	var v T
+	err := opts.unmarshal(src, &v)
+	if err != nil {
+		return fmt.Errorf("pgsql: decode %T: %w", j, err)
+	}
+	j.V, j.Valid = v, true
+	return nil
+}
+
+// Value implements driver.Valuer,
+// it returns string so pgx encodes it as text for both json and jsonb,
+// and adds jsonb version in binary format
+func (j JSONB[T]) Value() (driver.Value, error) {
+	if !j.Valid {
+		return nil, nil
+	}
+	b, err := jsonOptionsOf[T]().marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (j JSONB[T]) MarshalJSON() ([]byte, error) {
+	if !j.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(j.V)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (j *JSONB[T]) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		var zero T
+		j.V, j.Valid = zero, false
+		return nil
+	}
+	j.Valid = true
+	return json.Unmarshal(b, &j.V)
+}
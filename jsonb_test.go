@@ -0,0 +1,159 @@
+package pgsql_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xkamail/pgsql"
+)
+
+type jsonbObj struct {
+	A string `json:"a"`
+	B int    `json:"b"`
+}
+
+type (
+	jsonbNullError jsonbObj
+	jsonbStrict    jsonbObj
+	jsonbDecode    jsonbObj
+	jsonbCustom    jsonbObj
+)
+
+var errJSONBDecode = errors.New("decode")
+
+func init() {
+	pgsql.RegisterJSONOptions[jsonbNullError](pgsql.JSONOptions{Null: pgsql.JSONNullError})
+	pgsql.RegisterJSONOptions[jsonbStrict](pgsql.JSONOptions{DisallowUnknownFields: true})
+	pgsql.RegisterJSONOptions[jsonbDecode](pgsql.JSONOptions{
+		Unmarshal: func([]byte, any) error { return errJSONBDecode },
+	})
+	pgsql.RegisterJSONOptions[jsonbCustom](pgsql.JSONOptions{
+		Marshal: func(any) ([]byte, error) { return []byte(`"custom"`), nil },
+	})
+}
+
+func TestJSONB(t *testing.T) {
+	t.Parallel()
+
+	type row struct {
+		ID    int64
+		Value pgsql.JSONB[jsonbObj]
+		Ptr   pgsql.JSONB[*jsonbObj]
+	}
+
+	rows := newTestRows([]string{"id", "value", "ptr"}, []uint32{pgtype.Int8OID, pgtype.JSONBOID, pgtype.JSONOID},
+		[]*string{str("1"), str(`{"a": "x", "b": 1}`), str(`{"a": "y"}`)},
+		[]*string{str("2"), nil, nil},
+	)
+	xs, err := pgx.CollectRows(rows, pgsql.RowToAddrOfStructByName[row](nil))
+	require.NoError(t, err)
+	require.Len(t, xs, 2)
+
+	assert.Equal(t, pgsql.NewJSONB(jsonbObj{"x", 1}), xs[0].Value)
+	assert.Equal(t, pgsql.NewJSONB(&jsonbObj{A: "y"}), xs[0].Ptr)
+	assert.False(t, xs[1].Value.Valid)
+	assert.Equal(t, jsonbObj{}, xs[1].Value.V)
+	assert.False(t, xs[1].Ptr.Valid)
+	assert.Nil(t, xs[1].Ptr.V)
+
+	t.Run("Options", func(t *testing.T) {
+		type row struct {
+			Strict pgsql.JSONB[jsonbStrict]
+			Null   pgsql.JSONB[jsonbNullError]
+		}
+
+		rows := newTestRows([]string{"strict", "null"}, []uint32{pgtype.JSONBOID, pgtype.JSONBOID},
+			[]*string{str(`{"a": "x", "c": 1}`), str(`{}`)},
+		)
+		_, err := pgx.CollectRows(rows, pgsql.RowToAddrOfStructByName[row](nil))
+		assert.ErrorContains(t, err, `unknown field "c"`)
+
+		rows = newTestRows([]string{"strict", "null"}, []uint32{pgtype.JSONBOID, pgtype.JSONBOID},
+			[]*string{str(`{"a": "x"}`), nil},
+		)
+		_, err = pgx.CollectRows(rows, pgsql.RowToAddrOfStructByName[row](nil))
+		assert.ErrorIs(t, err, pgsql.ErrJSONNull)
+	})
+}
+
+func TestJSONB_Scan(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Null error", func(t *testing.T) {
+		var x pgsql.JSONB[jsonbNullError]
+		assert.ErrorIs(t, x.Scan(nil), pgsql.ErrJSONNull)
+	})
+
+	t.Run("Null zero", func(t *testing.T) {
+		x := pgsql.NewJSONB(jsonbObj{"a", 1})
+		assert.NoError(t, x.Scan(nil))
+		assert.False(t, x.Valid)
+		assert.Equal(t, jsonbObj{}, x.V)
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		var x pgsql.JSONB[jsonbStrict]
+		assert.Error(t, x.Scan([]byte(`{"a": "x", "c": 1}`)))
+		assert.NoError(t, x.Scan(`{"a": "x"}`))
+		assert.Equal(t, pgsql.NewJSONB(jsonbStrict{A: "x"}), x)
+
+		var y pgsql.JSONB[jsonbObj]
+		assert.NoError(t, y.Scan([]byte(`{"a": "x", "c": 1}`)))
+	})
+
+	t.Run("Unmarshal", func(t *testing.T) {
+		var x pgsql.JSONB[jsonbDecode]
+		assert.ErrorIs(t, x.Scan([]byte(`{}`)), errJSONBDecode)
+	})
+
+	t.Run("Invalid source", func(t *testing.T) {
+		var x pgsql.JSONB[jsonbObj]
+		assert.Error(t, x.Scan(1))
+	})
+}
+
+func TestJSONB_Encode(t *testing.T) {
+	t.Parallel()
+
+	m := pgtype.NewMap()
+	v := pgsql.NewJSONB(jsonbObj{"x", 1})
+
+	b, err := m.Encode(pgtype.JSONOID, pgtype.BinaryFormatCode, v, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":"x","b":1}`, string(b))
+
+	b, err = m.Encode(pgtype.JSONBOID, pgtype.BinaryFormatCode, v, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "\x01"+`{"a":"x","b":1}`, string(b))
+
+	// unknown oid from simple protocol
+	b, err = m.Encode(0, pgtype.TextFormatCode, v, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":"x","b":1}`, string(b))
+
+	b, err = m.Encode(pgtype.JSONBOID, pgtype.TextFormatCode, pgsql.JSONB[jsonbObj]{}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, b)
+
+	b, err = m.Encode(pgtype.JSONBOID, pgtype.TextFormatCode, pgsql.NewJSONB(jsonbCustom{}), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `"custom"`, string(b))
+}
+
+func TestJSONB_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	b, err := json.Marshal([]pgsql.JSONB[jsonbObj]{pgsql.NewJSONB(jsonbObj{"x", 1}), {}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"a":"x","b":1},null]`, string(b))
+
+	var xs []pgsql.JSONB[jsonbObj]
+	assert.NoError(t, json.Unmarshal(b, &xs))
+	assert.Equal(t, []pgsql.JSONB[jsonbObj]{pgsql.NewJSONB(jsonbObj{"x", 1}), {}}, xs)
+}
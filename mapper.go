@@ -0,0 +1,262 @@
+package pgsql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// StructMapper maps row columns to struct fields by name.
+//
+// Column name of a field is the name in the tag,
+// or the field name converted by Naming if the tag is empty.
+// Tag "-" skips the field.
+//
+// Embedded structs without tag are flattened.
+// Struct or pointer to struct field with tag option "inline",
+// ex. `db:"author,inline"`, maps columns prefixed with "author.",
+// a pointer to struct is left nil when all its columns are null,
+// which useful for left join.
+//
+// Fields of each struct type are cached on first use,
+// so the mapper settings must not be changed after that,
+// create another StructMapper for different settings.
+type StructMapper struct {
+	// Tag is the struct tag, default is "db"
+	Tag string
+
+	// Naming converts field name to column name, nil will use SnakeCase
+	Naming func(field string) string
+
+	// Strict returns error when a column is not mapped to any field,
+	// fields without column are always left zero
+	Strict bool
+
+	cache sync.Map // map[reflect.Type]map[string]*fieldPath
+}
+
+// DefaultStructMapper is the default struct mapper
+var DefaultStructMapper = &StructMapper{}
+
+// RowToStructByName returns pgx.RowToFunc that scans row into T by column name using m,
+// nil m will use DefaultStructMapper
+func RowToStructByName[T any](m *StructMapper) pgx.RowToFunc[T] {
+	if m == nil {
+		m = DefaultStructMapper
+	}
+	return func(row pgx.CollectableRow) (T, error) {
+		var value T
+		err := row.Scan(&structRowScanner{m: m, dst: &value})
+		return value, err
+	}
+}
+
+// RowToAddrOfStructByName likes RowToStructByName but returns the address of T
+func RowToAddrOfStructByName[T any](m *StructMapper) pgx.RowToFunc[*T] {
+	f := RowToStructByName[T](m)
+	return func(row pgx.CollectableRow) (*T, error) {
+		value, err := f(row)
+		return &value, err
+	}
+}
+
+// SnakeCase converts s into snake_case, ex. UserID to user_id
+func SnakeCase(s string) string {
+	var b strings.Builder
+	rs := []rune(s)
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			// start new word when previous is lower or next is lower in acronym, ex. HTTPServer
+			if i > 0 && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1]) ||
+				(i+1 < len(rs) && unicode.IsLower(rs[i+1]) && unicode.IsUpper(rs[i-1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// fieldPath is the path from root struct to the field
+type fieldPath struct {
+	index []int
+	depth int
+
+	// ptr reports is there any pointer to struct in the path
+	ptr bool
+}
+
+func (m *StructMapper) tag() string {
+	if m.Tag == "" {
+		return "db"
+	}
+	return m.Tag
+}
+
+func (m *StructMapper) naming(s string) string {
+	if m.Naming == nil {
+		return SnakeCase(s)
+	}
+	return m.Naming(s)
+}
+
+// fields returns fields of t by lower case column name
+func (m *StructMapper) fields(t reflect.Type) (map[string]*fieldPath, error) {
+	if v, ok := m.cache.Load(t); ok {
+		return v.(map[string]*fieldPath), nil
+	}
+
+	fields := make(map[string]*fieldPath)
+	err := m.appendFields(fields, t, "", nil, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	m.cache.Store(t, fields)
+	return fields, nil
+}
+
+func (m *StructMapper) appendFields(fields map[string]*fieldPath, t reflect.Type, prefix string, index []int, depth int, ptr bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		tag, opts, _ := strings.Cut(sf.Tag.Get(m.tag()), ",")
+		if tag == "-" {
+			continue
+		}
+		idx := append(append([]int{}, index...), i)
+
+		ft := sf.Type
+		isPtr := ft.Kind() == reflect.Pointer
+		if isPtr {
+			ft = ft.Elem()
+		}
+
+		// unexported embedded pointer can not be allocated
+		if sf.Anonymous && tag == "" && ft.Kind() == reflect.Struct && (sf.IsExported() || !isPtr) {
+			err := m.appendFields(fields, ft, prefix, idx, depth+1, ptr || isPtr)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = m.naming(sf.Name)
+		}
+		name = prefix + name
+
+		if opts == "inline" {
+			if ft.Kind() != reflect.Struct {
+				return fmt.Errorf("pgsql: inline field %s must be struct or pointer to struct", sf.Name)
+			}
+			err := m.appendFields(fields, ft, name+".", idx, depth+1, ptr || isPtr)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := strings.ToLower(name)
+		// shallower field wins like go embedded field
+		if p, ok := fields[key]; ok && p.depth <= depth {
+			continue
+		}
+		fields[key] = &fieldPath{index: idx, depth: depth, ptr: ptr}
+	}
+	return nil
+}
+
+// structRowScanner scans row into struct by column name
+type structRowScanner struct {
+	m   *StructMapper
+	dst any
+}
+
+func (rs *structRowScanner) ScanRow(rows pgx.Rows) error {
+	dstValue := reflect.ValueOf(rs.dst).Elem()
+	if dstValue.Kind() != reflect.Struct {
+		return fmt.Errorf("pgsql: dst must be pointer to struct, got %T", rs.dst)
+	}
+
+	fields, err := rs.m.fields(dstValue.Type())
+	if err != nil {
+		return err
+	}
+
+	fds := rows.FieldDescriptions()
+	targets := make([]any, len(fds))
+	var deferred []deferredField
+	for i, fd := range fds {
+		p, ok := fields[strings.ToLower(fd.Name)]
+		if !ok {
+			if rs.m.Strict {
+				return fmt.Errorf("pgsql: column %s is not mapped to any field of %s", fd.Name, dstValue.Type())
+			}
+			continue
+		}
+		if !p.ptr {
+			targets[i] = dstValue.FieldByIndex(p.index).Addr().Interface()
+			continue
+		}
+
+		// field inside pointer to struct, scan into pointer to detect null
+		f := deferredField{
+			path:   p,
+			holder: reflect.New(reflect.PointerTo(fieldType(dstValue.Type(), p.index))),
+		}
+		targets[i] = f.holder.Interface()
+		deferred = append(deferred, f)
+	}
+
+	err = rows.Scan(targets...)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range deferred {
+		v := f.holder.Elem()
+		if v.IsNil() {
+			continue
+		}
+		fieldByIndexAlloc(dstValue, f.path.index).Set(v.Elem())
+	}
+	return nil
+}
+
+type deferredField struct {
+	path   *fieldPath
+	holder reflect.Value // **F
+}
+
+func fieldType(t reflect.Type, index []int) reflect.Type {
+	for _, i := range index {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		t = t.Field(i).Type
+	}
+	return t
+}
+
+// fieldByIndexAlloc likes reflect.Value.FieldByIndex but allocates nil pointer to struct
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
@@ -0,0 +1,175 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xkamail/pgsql"
+)
+
+// testRows is the pgx.Rows of text format values that scans with pgx type map
+type testRows struct {
+	m      *pgtype.Map
+	fds    []pgconn.FieldDescription
+	values [][][]byte
+	pos    int
+}
+
+func newTestRows(columns []string, oids []uint32, values ...[]*string) *testRows {
+	rows := &testRows{m: pgtype.NewMap()}
+	for i, c := range columns {
+		rows.fds = append(rows.fds, pgconn.FieldDescription{
+			Name:        c,
+			DataTypeOID: oids[i],
+			Format:      pgtype.TextFormatCode,
+		})
+	}
+	for _, vs := range values {
+		var row [][]byte
+		for _, v := range vs {
+			if v == nil {
+				row = append(row, nil)
+				continue
+			}
+			row = append(row, []byte(*v))
+		}
+		rows.values = append(rows.values, row)
+	}
+	return rows
+}
+
+func str(s string) *string { return &s }
+
+func (r *testRows) Close()                                       {}
+func (r *testRows) Err() error                                   { return nil }
+func (r *testRows) CommandTag() pgconn.CommandTag                { return pgconn.NewCommandTag("SELECT") }
+func (r *testRows) FieldDescriptions() []pgconn.FieldDescription { return r.fds }
+func (r *testRows) Values() ([]any, error)                       { return nil, nil }
+func (r *testRows) RawValues() [][]byte                          { return r.values[r.pos-1] }
+func (r *testRows) Conn() *pgx.Conn                              { return nil }
+
+func (r *testRows) Next() bool {
+	r.pos++
+	return r.pos <= len(r.values)
+}
+
+func (r *testRows) Scan(dest ...any) error {
+	if len(dest) == 1 {
+		if rs, ok := dest[0].(pgx.RowScanner); ok {
+			return rs.ScanRow(r)
+		}
+	}
+	for i, d := range dest {
+		if d == nil {
+			continue
+		}
+		err := r.m.Scan(r.fds[i].DataTypeOID, pgtype.TextFormatCode, r.values[r.pos-1][i], d)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestSnakeCase(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"ID":         "id",
+		"UserID":     "user_id",
+		"Name":       "name",
+		"CreatedAt":  "created_at",
+		"HTTPServer": "http_server",
+		"Field1Name": "field1_name",
+		"already_ok": "already_ok",
+	}
+	for in, out := range cases {
+		assert.Equal(t, out, pgsql.SnakeCase(in), in)
+	}
+}
+
+type testBase struct {
+	ID        int64
+	CreatedAt string
+}
+
+type testAuthor struct {
+	ID   int64
+	Name string
+}
+
+type testPost struct {
+	testBase
+	Title   string      `db:"post_title"`
+	Ignored string      `db:"-"`
+	Author  *testAuthor `db:"author,inline"`
+}
+
+func TestRowToStructByName(t *testing.T) {
+	t.Parallel()
+
+	columns := []string{"post_title", "id", "author.id", "author.name", "created_at"}
+	oids := []uint32{pgtype.TextOID, pgtype.Int8OID, pgtype.Int8OID, pgtype.TextOID, pgtype.TextOID}
+
+	t.Run("Collect", func(t *testing.T) {
+		rows := newTestRows(columns, oids,
+			[]*string{str("hello"), str("1"), str("10"), str("alice"), str("today")},
+			[]*string{str("world"), str("2"), nil, nil, str("yesterday")},
+		)
+
+		xs, err := pgx.CollectRows(rows, pgsql.RowToAddrOfStructByName[testPost](nil))
+		require.NoError(t, err)
+		require.Len(t, xs, 2)
+
+		assert.Equal(t, int64(1), xs[0].ID)
+		assert.Equal(t, "today", xs[0].CreatedAt)
+		assert.Equal(t, "hello", xs[0].Title)
+		assert.Equal(t, &testAuthor{ID: 10, Name: "alice"}, xs[0].Author)
+
+		assert.Equal(t, int64(2), xs[1].ID)
+		assert.Equal(t, "world", xs[1].Title)
+		assert.Nil(t, xs[1].Author)
+	})
+
+	t.Run("Unmapped column", func(t *testing.T) {
+		newRows := func() *testRows {
+			return newTestRows([]string{"id", "extra"}, []uint32{pgtype.Int8OID, pgtype.TextOID},
+				[]*string{str("1"), str("x")},
+			)
+		}
+
+		x, err := pgx.CollectOneRow(newRows(), pgsql.RowToStructByName[testAuthor](nil))
+		assert.NoError(t, err)
+		assert.Equal(t, testAuthor{ID: 1}, x)
+
+		_, err = pgx.CollectOneRow(newRows(), pgsql.RowToStructByName[testAuthor](&pgsql.StructMapper{Strict: true}))
+		assert.ErrorContains(t, err, "extra")
+	})
+
+	t.Run("Naming", func(t *testing.T) {
+		rows := newTestRows([]string{"ID", "NAME"}, []uint32{pgtype.Int8OID, pgtype.TextOID},
+			[]*string{str("1"), str("bob")},
+		)
+		m := &pgsql.StructMapper{Naming: func(s string) string { return s }, Strict: true}
+		x, err := pgx.CollectOneRow(rows, pgsql.RowToStructByName[testAuthor](m))
+		assert.NoError(t, err)
+		assert.Equal(t, testAuthor{ID: 1, Name: "bob"}, x)
+	})
+
+	t.Run("Tag", func(t *testing.T) {
+		type user struct {
+			Name string `json:"username"`
+		}
+		rows := newTestRows([]string{"username"}, []uint32{pgtype.TextOID},
+			[]*string{str("bob")},
+		)
+		x, err := pgx.CollectOneRow(rows, pgsql.RowToStructByName[user](&pgsql.StructMapper{Tag: "json"}))
+		assert.NoError(t, err)
+		assert.Equal(t, "bob", x.Name)
+	})
+}
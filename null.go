@@ -3,9 +3,12 @@ package pgsql
 import (
 	"database/sql"
 	"database/sql/driver"
-	_ "unsafe"
 )
 
+// Null wraps v to scan null into zero value and convert zero value into sql null,
+// it works with database/sql and pgx through database/sql interfaces.
+//
+// Use NullOf for pgx native codecs.
 func Null[T comparable](v *T) interface {
 	driver.Valuer
 	sql.Scanner
@@ -18,16 +21,14 @@ type null[T comparable] struct {
 }
 
 func (s *null[T]) Scan(src any) error {
-	*s.value = *(new(T))
-	if src == nil {
-		return nil
-	}
-
-	return convertAssign(s.value, src)
+	var n sql.Null[T]
+	err := n.Scan(src)
+	*s.value = n.V
+	return err
 }
 
 func (s null[T]) Value() (driver.Value, error) {
-	if s.value == nil || isZero(*s.value) || *s.value == *(new(T)) {
+	if s.value == nil || isZeroValue(*s.value) {
 		return nil, nil
 	}
 	if v, ok := any(s.value).(driver.Valuer); ok {
@@ -47,5 +48,6 @@ func isZero(v any) bool {
 	return false
 }
 
-//go:linkname convertAssign database/sql.convertAssign
-func convertAssign(dst, src any) error
+func isZeroValue[T comparable](v T) bool {
+	return isZero(v) || v == *(new(T))
+}
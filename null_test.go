@@ -59,3 +59,34 @@ func (v testValuer) Value() (driver.Value, error) {
 func (v testValuer) IsZero() bool {
 	return v.x == 0
 }
+
+func TestNull_Scan(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Int64 valid", func(t *testing.T) {
+		x := 2
+		err := pgsql.Null(&x).Scan(int64(1))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, x)
+	})
+
+	t.Run("Int64 null", func(t *testing.T) {
+		x := 2
+		err := pgsql.Null(&x).Scan(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, x)
+	})
+
+	t.Run("String from bytes", func(t *testing.T) {
+		var x string
+		err := pgsql.Null(&x).Scan([]byte("a"))
+		assert.NoError(t, err)
+		assert.Equal(t, "a", x)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		var x int
+		err := pgsql.Null(&x).Scan("a")
+		assert.Error(t, err)
+	})
+}
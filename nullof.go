@@ -0,0 +1,91 @@
+package pgsql
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// NullOf wraps v to scan null into zero value and encode zero value as null,
+// using pgx codec of T, ex. time.Time, [16]byte uuid, or custom registered types.
+//
+// The type map must be registered with Register.
+func NullOf[T comparable](v *T) *NullValue[T] {
+	return &NullValue[T]{v}
+}
+
+// NullValue is the pgx native null wrapper created by NullOf
+type NullValue[T comparable] struct {
+	value *T
+}
+
+func (n *NullValue[T]) ptr() any {
+	return n.value
+}
+
+func (n *NullValue[T]) setZero() {
+	*n.value = *(new(T))
+}
+
+func (n *NullValue[T]) isNull() bool {
+	return n.value == nil || isZeroValue(*n.value)
+}
+
+// nullWrapper is implemented by *NullValue[T]
+type nullWrapper interface {
+	ptr() any
+	setZero()
+	isNull() bool
+}
+
+// Register registers pgsql types to pgx type map,
+// call it in pgxpool.Config.AfterConnect with conn.TypeMap().
+func Register(m *pgtype.Map) {
+	m.TryWrapScanPlanFuncs = append([]pgtype.TryWrapScanPlanFunc{TryWrapNullScanPlan}, m.TryWrapScanPlanFuncs...)
+	m.TryWrapEncodePlanFuncs = append([]pgtype.TryWrapEncodePlanFunc{TryWrapNullEncodePlan}, m.TryWrapEncodePlanFuncs...)
+}
+
+// TryWrapNullScanPlan tries to wrap *NullValue[T] scan plan
+func TryWrapNullScanPlan(target any) (plan pgtype.WrappedScanPlanNextSetter, nextTarget any, ok bool) {
+	n, ok := target.(nullWrapper)
+	if !ok {
+		return nil, nil, false
+	}
+	return &nullScanPlan{}, n.ptr(), true
+}
+
+type nullScanPlan struct {
+	next pgtype.ScanPlan
+}
+
+func (plan *nullScanPlan) SetNext(next pgtype.ScanPlan) { plan.next = next }
+
+func (plan *nullScanPlan) Scan(src []byte, target any) error {
+	n := target.(nullWrapper)
+	if src == nil {
+		n.setZero()
+		return nil
+	}
+	return plan.next.Scan(src, n.ptr())
+}
+
+// TryWrapNullEncodePlan tries to wrap *NullValue[T] encode plan
+func TryWrapNullEncodePlan(value any) (plan pgtype.WrappedEncodePlanNextSetter, nextValue any, ok bool) {
+	n, ok := value.(nullWrapper)
+	if !ok {
+		return nil, nil, false
+	}
+	return &nullEncodePlan{}, n.ptr(), true
+}
+
+type nullEncodePlan struct {
+	next pgtype.EncodePlan
+}
+
+func (plan *nullEncodePlan) SetNext(next pgtype.EncodePlan) { plan.next = next }
+
+func (plan *nullEncodePlan) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	n := value.(nullWrapper)
+	if n.isNull() {
+		return nil, nil
+	}
+	return plan.next.Encode(n.ptr(), buf)
+}
@@ -0,0 +1,118 @@
+package pgsql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xkamail/pgsql"
+)
+
+type testLabel string
+
+func newTypeMap() *pgtype.Map {
+	m := pgtype.NewMap()
+	pgsql.Register(m)
+	return m
+}
+
+func TestNullOf_Scan(t *testing.T) {
+	t.Parallel()
+
+	m := newTypeMap()
+
+	t.Run("UUID", func(t *testing.T) {
+		id := [16]byte{1, 2, 3}
+		src, err := m.Encode(pgtype.UUIDOID, pgtype.BinaryFormatCode, id, nil)
+		require.NoError(t, err)
+
+		var x [16]byte
+		err = m.Scan(pgtype.UUIDOID, pgtype.BinaryFormatCode, src, pgsql.NullOf(&x))
+		assert.NoError(t, err)
+		assert.Equal(t, id, x)
+
+		err = m.Scan(pgtype.UUIDOID, pgtype.BinaryFormatCode, nil, pgsql.NullOf(&x))
+		assert.NoError(t, err)
+		assert.Equal(t, [16]byte{}, x)
+	})
+
+	t.Run("Time", func(t *testing.T) {
+		now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+		src, err := m.Encode(pgtype.TimestamptzOID, pgtype.BinaryFormatCode, now, nil)
+		require.NoError(t, err)
+
+		var x time.Time
+		err = m.Scan(pgtype.TimestamptzOID, pgtype.BinaryFormatCode, src, pgsql.NullOf(&x))
+		assert.NoError(t, err)
+		assert.True(t, now.Equal(x))
+
+		err = m.Scan(pgtype.TimestamptzOID, pgtype.BinaryFormatCode, nil, pgsql.NullOf(&x))
+		assert.NoError(t, err)
+		assert.True(t, x.IsZero())
+	})
+
+	t.Run("Custom type", func(t *testing.T) {
+		x := testLabel("b")
+		err := m.Scan(pgtype.TextOID, pgtype.TextFormatCode, []byte("a"), pgsql.NullOf(&x))
+		assert.NoError(t, err)
+		assert.Equal(t, testLabel("a"), x)
+
+		err = m.Scan(pgtype.TextOID, pgtype.TextFormatCode, nil, pgsql.NullOf(&x))
+		assert.NoError(t, err)
+		assert.Equal(t, testLabel(""), x)
+	})
+
+	t.Run("Int64", func(t *testing.T) {
+		x := int64(2)
+		err := m.Scan(pgtype.Int8OID, pgtype.TextFormatCode, []byte("1"), pgsql.NullOf(&x))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), x)
+
+		err = m.Scan(pgtype.Int8OID, pgtype.TextFormatCode, nil, pgsql.NullOf(&x))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), x)
+	})
+}
+
+func TestNullOf_Encode(t *testing.T) {
+	t.Parallel()
+
+	m := newTypeMap()
+
+	t.Run("Valid", func(t *testing.T) {
+		x := int64(1)
+		buf, err := m.Encode(pgtype.Int8OID, pgtype.TextFormatCode, pgsql.NullOf(&x), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("1"), buf)
+	})
+
+	t.Run("Zero", func(t *testing.T) {
+		var x int64
+		buf, err := m.Encode(pgtype.Int8OID, pgtype.TextFormatCode, pgsql.NullOf(&x), nil)
+		assert.NoError(t, err)
+		assert.Nil(t, buf)
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		buf, err := m.Encode(pgtype.Int8OID, pgtype.TextFormatCode, pgsql.NullOf[int64](nil), nil)
+		assert.NoError(t, err)
+		assert.Nil(t, buf)
+	})
+
+	t.Run("Time zero", func(t *testing.T) {
+		var x time.Time
+		buf, err := m.Encode(pgtype.TimestamptzOID, pgtype.BinaryFormatCode, pgsql.NullOf(&x), nil)
+		assert.NoError(t, err)
+		assert.Nil(t, buf)
+	})
+
+	t.Run("UUID", func(t *testing.T) {
+		x := [16]byte{1}
+		buf, err := m.Encode(pgtype.UUIDOID, pgtype.BinaryFormatCode, pgsql.NullOf(&x), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, x[:], buf)
+	})
+}
@@ -0,0 +1,53 @@
+package pgsql
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicMode controls how panic inside transaction is handled
+type PanicMode int
+
+const (
+	// PanicPropagate rollbacks the transaction and let the panic continue (default)
+	PanicPropagate PanicMode = iota
+
+	// PanicRecover rollbacks the transaction and returns *PanicError
+	PanicRecover
+
+	// PanicRepanic rollbacks the transaction and panics with *PanicError
+	PanicRepanic
+)
+
+// PanicError is the panic recovered from inside transaction
+type PanicError struct {
+	// Value is the value passed to panic
+	Value any
+
+	// Stack is the stack trace of the goroutine when panic
+	Stack []byte
+}
+
+// NewPanicError creates new panic error from recovered value,
+// it must be called from deferred function to capture the panic stack
+func NewPanicError(v any) *PanicError {
+	if e, ok := v.(*PanicError); ok {
+		return e
+	}
+	return &PanicError{
+		Value: v,
+		Stack: debug.Stack(),
+	}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("pgsql: panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+//go:build ignore
+
+// gen generates errcode.go from errcodes.txt
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+)
+
+type code struct {
+	Code  string
+	Macro string
+	Spec  string
+	Name  string
+}
+
+type class struct {
+	Class string
+	Desc  string
+	Name  string
+	Codes []*code
+}
+
+var reSection = regexp.MustCompile(`^Section: Class (\w\w) - (.+)$`)
+
+var initialisms = map[string]string{
+	"fdw":     "FDW",
+	"io":      "IO",
+	"json":    "JSON",
+	"plpgsql": "PLPGSQL",
+	"sql":     "SQL",
+	"srf":     "SRF",
+	"xml":     "XML",
+}
+
+func camel(words []string) string {
+	var b strings.Builder
+	for _, w := range words {
+		w = strings.ToLower(w)
+		if w == "" {
+			continue
+		}
+		if s, ok := initialisms[w]; ok {
+			b.WriteString(s)
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+func main() {
+	f, err := os.Open("errcodes.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	var classes []*class
+	specs := map[string]int{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := reSection.FindStringSubmatch(line); m != nil {
+			desc := m[2]
+			if i := strings.Index(desc, " ("); i > 0 {
+				desc = desc[:i]
+			}
+			classes = append(classes, &class{
+				Class: m[1],
+				Desc:  desc,
+				Name:  "Class" + camel(regexp.MustCompile(`[^A-Za-z0-9]+`).Split(strings.ReplaceAll(desc, "/", ""), -1)),
+			})
+			continue
+		}
+		fs := strings.Fields(line)
+		if len(fs) < 3 || len(classes) == 0 {
+			continue
+		}
+		c := code{Code: fs[0], Macro: strings.TrimPrefix(fs[2], "ERRCODE_")}
+		if len(fs) > 3 {
+			c.Spec = fs[3]
+			specs[c.Spec]++
+		}
+		cl := classes[len(classes)-1]
+		cl.Codes = append(cl.Codes, &c)
+	}
+	if err := sc.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, cl := range classes {
+		for _, c := range cl.Codes {
+			if c.Spec != "" && specs[c.Spec] == 1 {
+				c.Name = camel(strings.Split(c.Spec, "_"))
+				continue
+			}
+			// condition name is not unique, use macro name instead
+			// ex. E_R_E_MODIFYING_SQL_DATA_NOT_PERMITTED => EREModifyingSQLDataNotPermitted
+			var words []string
+			abbr := ""
+			for _, w := range strings.Split(c.Macro, "_") {
+				if len(w) == 1 {
+					abbr += w
+					continue
+				}
+				if abbr != "" {
+					words = append(words, abbr)
+					initialisms[strings.ToLower(abbr)] = abbr
+					abbr = ""
+				}
+				words = append(words, w)
+			}
+			c.Name = camel(words)
+			if c.Spec == "" {
+				c.Spec = strings.ToLower(c.Macro)
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "// Code generated by gen.go from errcodes.txt; DO NOT EDIT.")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "package pgcode")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "// Error classes")
+	fmt.Fprintln(&b, "const (")
+	for _, cl := range classes {
+		fmt.Fprintf(&b, "%s = %q\n", cl.Name, cl.Class)
+	}
+	fmt.Fprintln(&b, ")")
+	for _, cl := range classes {
+		fmt.Fprintln(&b)
+		fmt.Fprintf(&b, "// Class %s - %s\n", cl.Class, cl.Desc)
+		fmt.Fprintln(&b, "const (")
+		for _, c := range cl.Codes {
+			fmt.Fprintf(&b, "%s = %q\n", c.Name, c.Code)
+		}
+		fmt.Fprintln(&b, ")")
+	}
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "var classNames = map[string]string{")
+	for _, cl := range classes {
+		fmt.Fprintf(&b, "%s: %q,\n", cl.Name, cl.Desc)
+	}
+	fmt.Fprintln(&b, "}")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "var names = map[string]string{")
+	for _, cl := range classes {
+		for _, c := range cl.Codes {
+			fmt.Fprintf(&b, "%s: %q,\n", c.Name, c.Spec)
+		}
+	}
+	fmt.Fprintln(&b, "}")
+
+	p, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = os.WriteFile("errcode.go", p, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
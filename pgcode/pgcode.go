@@ -0,0 +1,26 @@
+// Package pgcode contains PostgreSQL error codes (SQLSTATE)
+//
+// see https://www.postgresql.org/docs/current/errcodes-appendix.html
+package pgcode
+
+//go:generate go run gen.go
+
+// Class returns the class of given code, which is the first 2 characters
+func Class(code string) string {
+	if len(code) < 2 {
+		return ""
+	}
+	return code[:2]
+}
+
+// Name returns the condition name of given code,
+// ex. "23505" will return "unique_violation"
+func Name(code string) string {
+	return names[code]
+}
+
+// ClassName returns the description of given class,
+// ex. "23" will return "Integrity Constraint Violation"
+func ClassName(class string) string {
+	return classNames[class]
+}
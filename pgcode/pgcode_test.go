@@ -0,0 +1,28 @@
+package pgcode_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xkamail/pgsql/pgcode"
+)
+
+func TestName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "unique_violation", pgcode.Name(pgcode.UniqueViolation))
+	assert.Equal(t, "serialization_failure", pgcode.Name("40001"))
+	assert.Equal(t, "modifying_sql_data_not_permitted", pgcode.Name(pgcode.EREModifyingSQLDataNotPermitted))
+	assert.Equal(t, "", pgcode.Name("99999"))
+}
+
+func TestClass(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, pgcode.ClassIntegrityConstraintViolation, pgcode.Class(pgcode.UniqueViolation))
+	assert.Equal(t, pgcode.ClassTransactionRollback, pgcode.Class(pgcode.DeadlockDetected))
+	assert.Equal(t, "", pgcode.Class("4"))
+	assert.Equal(t, "Integrity Constraint Violation", pgcode.ClassName("23"))
+	assert.Equal(t, "PL/pgSQL Error", pgcode.ClassName(pgcode.ClassPLPGSQLError))
+}
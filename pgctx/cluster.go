@@ -0,0 +1,153 @@
+package pgctx
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Cluster is the DB that routes queries between primary and replicas.
+//
+// Exec and read-write tx always use the primary,
+// QueryRow and Query use a replica, read-only tx begins on a replica.
+// Query that writes (ex. insert ... returning) must use UsePrimary.
+type Cluster struct {
+	Primary  DB
+	Replicas []DB
+
+	// StickyWindow routes all queries to the primary for the duration after a write,
+	// to read your own writes from the same context.
+	// The context must be created by NewClusterContext or ClusterMiddleware.
+	StickyWindow time.Duration
+
+	next atomic.Uint32
+}
+
+var _ DB = &Cluster{}
+
+// NewCluster creates new cluster
+func NewCluster(primary DB, replicas ...DB) *Cluster {
+	return &Cluster{
+		Primary:  primary,
+		Replicas: replicas,
+	}
+}
+
+// NewClusterContext creates new context with cluster,
+// and tracks writes in the context for c.StickyWindow
+func NewClusterContext(ctx context.Context, c *Cluster) context.Context {
+	ctx = context.WithValue(ctx, ctxKeySticky{c}, &sticky{})
+	return NewContext(ctx, c)
+}
+
+// ClusterMiddleware injects cluster into request's context
+func ClusterMiddleware(c *Cluster) func(h http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(NewClusterContext(r.Context(), c))
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UsePrimary creates new context that routes all queries to the primary
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKeyUsePrimary{}, true)
+}
+
+type (
+	ctxKeySticky     struct{ c *Cluster }
+	ctxKeyUsePrimary struct{}
+)
+
+type sticky struct {
+	lastWrite atomic.Int64 // unix nano
+}
+
+func (s *sticky) markWrite() {
+	if s != nil {
+		s.lastWrite.Store(time.Now().UnixNano())
+	}
+}
+
+// sticky returns the write tracker in ctx, or nil if not tracked
+func (c *Cluster) sticky(ctx context.Context) *sticky {
+	if c.StickyWindow <= 0 {
+		return nil
+	}
+	s, _ := ctx.Value(ctxKeySticky{c}).(*sticky)
+	return s
+}
+
+func (c *Cluster) isSticky(ctx context.Context) bool {
+	s := c.sticky(ctx)
+	if s == nil {
+		return false
+	}
+	t := s.lastWrite.Load()
+	return t > 0 && time.Since(time.Unix(0, t)) < c.StickyWindow
+}
+
+// reader returns the db for read
+func (c *Cluster) reader(ctx context.Context) DB {
+	if len(c.Replicas) == 0 || c.isSticky(ctx) {
+		return c.Primary
+	}
+	if p, _ := ctx.Value(ctxKeyUsePrimary{}).(bool); p {
+		return c.Primary
+	}
+	i := c.next.Add(1) - 1
+	return c.Replicas[i%uint32(len(c.Replicas))]
+}
+
+// QueryRow calls QueryRow on a replica
+func (c *Cluster) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return c.reader(ctx).QueryRow(ctx, sql, args...)
+}
+
+// Query calls Query on a replica
+func (c *Cluster) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return c.reader(ctx).Query(ctx, sql, args...)
+}
+
+// Exec calls Exec on the primary
+func (c *Cluster) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	defer c.sticky(ctx).markWrite()
+	return c.Primary.Exec(ctx, sql, arguments...)
+}
+
+// BeginTx begins read-only tx on a replica, and read-write tx on the primary,
+// the write is tracked when the read-write tx commits
+func (c *Cluster) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	if txOptions.AccessMode == pgx.ReadOnly {
+		return c.reader(ctx).BeginTx(ctx, txOptions)
+	}
+	tx, err := c.Primary.BeginTx(ctx, txOptions)
+	if err != nil {
+		return nil, err
+	}
+	s := c.sticky(ctx)
+	if s == nil {
+		return tx, nil
+	}
+	return &clusterTx{Tx: tx, sticky: s}, nil
+}
+
+// clusterTx tracks the write at commit,
+// since tx may run longer than StickyWindow
+type clusterTx struct {
+	pgx.Tx
+	sticky *sticky
+}
+
+// Commit commits tx and tracks the write even if commit fails,
+// since the tx may be committed before the error
+func (tx *clusterTx) Commit(ctx context.Context) error {
+	err := tx.Tx.Commit(ctx)
+	tx.sticky.markWrite()
+	return err
+}
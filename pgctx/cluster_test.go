@@ -0,0 +1,173 @@
+package pgctx_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xkamail/pgsql/pgctx"
+)
+
+func newCluster(t *testing.T) (*pgctx.Cluster, pgxmock.PgxPoolIface, pgxmock.PgxPoolIface) {
+	t.Helper()
+	primary, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	replica, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	return pgctx.NewCluster(primary, replica), primary, replica
+}
+
+func TestCluster(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Route", func(t *testing.T) {
+		c, primary, replica := newCluster(t)
+		ctx := pgctx.NewClusterContext(context.Background(), c)
+
+		replica.ExpectQuery("select 1").WillReturnRows(pgxmock.NewRows([]string{"x"}).AddRow(1))
+		replica.ExpectQuery("select 2").WillReturnRows(pgxmock.NewRows([]string{"x"}).AddRow(2))
+		primary.ExpectExec("update").WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		var x int
+		assert.NoError(t, pgctx.QueryRow(ctx, "select 1").Scan(&x))
+		rows, err := pgctx.Query(ctx, "select 2")
+		assert.NoError(t, err)
+		rows.Close()
+		_, err = pgctx.Exec(ctx, "update t set x = 1")
+		assert.NoError(t, err)
+
+		assert.NoError(t, primary.ExpectationsWereMet())
+		assert.NoError(t, replica.ExpectationsWereMet())
+	})
+
+	t.Run("Tx", func(t *testing.T) {
+		c, primary, replica := newCluster(t)
+		ctx := pgctx.NewClusterContext(context.Background(), c)
+
+		primary.ExpectBegin()
+		primary.ExpectQuery("select 1").WillReturnRows(pgxmock.NewRows([]string{"x"}).AddRow(1))
+		primary.ExpectCommit()
+		replica.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		replica.ExpectQuery("select 2").WillReturnRows(pgxmock.NewRows([]string{"x"}).AddRow(2))
+		replica.ExpectCommit()
+
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			var x int
+			return pgctx.QueryRow(ctx, "select 1").Scan(&x)
+		})
+		assert.NoError(t, err)
+		err = pgctx.RunInReadOnlyTx(ctx, func(ctx context.Context) error {
+			var x int
+			return pgctx.QueryRow(ctx, "select 2").Scan(&x)
+		})
+		assert.NoError(t, err)
+
+		assert.NoError(t, primary.ExpectationsWereMet())
+		assert.NoError(t, replica.ExpectationsWereMet())
+	})
+
+	t.Run("Sticky", func(t *testing.T) {
+		c, primary, replica := newCluster(t)
+		c.StickyWindow = 50 * time.Millisecond
+		ctx := pgctx.NewClusterContext(context.Background(), c)
+		otherCtx := pgctx.NewClusterContext(context.Background(), c)
+
+		primary.ExpectExec("insert").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		primary.ExpectQuery("select 1").WillReturnRows(pgxmock.NewRows([]string{"x"}).AddRow(1))
+		replica.ExpectQuery("select 2").WillReturnRows(pgxmock.NewRows([]string{"x"}).AddRow(2))
+		replica.ExpectQuery("select 3").WillReturnRows(pgxmock.NewRows([]string{"x"}).AddRow(3))
+
+		var x int
+		_, err := pgctx.Exec(ctx, "insert into t values (1)")
+		assert.NoError(t, err)
+		assert.NoError(t, pgctx.QueryRow(ctx, "select 1").Scan(&x))
+		assert.NoError(t, pgctx.QueryRow(otherCtx, "select 2").Scan(&x))
+		time.Sleep(60 * time.Millisecond)
+		assert.NoError(t, pgctx.QueryRow(ctx, "select 3").Scan(&x))
+
+		assert.NoError(t, primary.ExpectationsWereMet())
+		assert.NoError(t, replica.ExpectationsWereMet())
+	})
+
+	t.Run("Sticky long tx", func(t *testing.T) {
+		c, primary, replica := newCluster(t)
+		c.StickyWindow = 50 * time.Millisecond
+		ctx := pgctx.NewClusterContext(context.Background(), c)
+
+		primary.ExpectBegin()
+		primary.ExpectExec("insert").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		primary.ExpectCommit()
+		primary.ExpectQuery("select 1").WillReturnRows(pgxmock.NewRows([]string{"x"}).AddRow(1))
+
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			_, err := pgctx.Exec(ctx, "insert into t values (1)")
+			time.Sleep(60 * time.Millisecond)
+			return err
+		})
+		assert.NoError(t, err)
+
+		var x int
+		assert.NoError(t, pgctx.QueryRow(ctx, "select 1").Scan(&x))
+
+		assert.NoError(t, primary.ExpectationsWereMet())
+		assert.NoError(t, replica.ExpectationsWereMet())
+	})
+
+	t.Run("UsePrimary", func(t *testing.T) {
+		c, primary, replica := newCluster(t)
+
+		primary.ExpectQuery("insert").WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+
+		ctx := pgctx.UsePrimary(pgctx.NewClusterContext(context.Background(), c))
+		var id int
+		assert.NoError(t, pgctx.QueryRow(ctx, "insert into t values (1) returning id").Scan(&id))
+
+		assert.NoError(t, primary.ExpectationsWereMet())
+		assert.NoError(t, replica.ExpectationsWereMet())
+	})
+
+	t.Run("Round Robin", func(t *testing.T) {
+		primary, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		r1, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		r2, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		ctx := pgctx.NewContext(context.Background(), pgctx.NewCluster(primary, r1, r2))
+
+		r1.ExpectQuery("select 1").WillReturnRows(pgxmock.NewRows([]string{"x"}).AddRow(1))
+		r2.ExpectQuery("select 2").WillReturnRows(pgxmock.NewRows([]string{"x"}).AddRow(2))
+
+		var x int
+		assert.NoError(t, pgctx.QueryRow(ctx, "select 1").Scan(&x))
+		assert.NoError(t, pgctx.QueryRow(ctx, "select 2").Scan(&x))
+
+		assert.NoError(t, r1.ExpectationsWereMet())
+		assert.NoError(t, r2.ExpectationsWereMet())
+	})
+}
+
+func TestClusterMiddleware(t *testing.T) {
+	t.Parallel()
+
+	c, _, replica := newCluster(t)
+	replica.ExpectQuery("select 1").WillReturnRows(pgxmock.NewRows([]string{"x"}).AddRow(1))
+
+	called := false
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	pgctx.ClusterMiddleware(c)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		var x int
+		assert.NoError(t, pgctx.QueryRow(r.Context(), "select 1").Scan(&x))
+	})).ServeHTTP(w, r)
+	assert.True(t, called)
+	assert.NoError(t, replica.ExpectationsWereMet())
+}
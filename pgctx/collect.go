@@ -0,0 +1,96 @@
+package pgctx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/xkamail/pgsql"
+)
+
+// ErrNoRows is returned by CollectScalar when query returns no rows,
+// it is pgx.ErrNoRows
+var ErrNoRows = pgx.ErrNoRows
+
+// CollectScalars scans first column of each row into []T
+func CollectScalars[T any](ctx context.Context, sql string, args ...any) ([]T, error) {
+	return CollectScalarsKey[T](ctx, nil, sql, args...)
+}
+
+// CollectScalarsKey likes CollectScalars but uses keyed db or tx
+func CollectScalarsKey[T any](ctx context.Context, key any, sql string, args ...any) ([]T, error) {
+	return collectRows(ctx, key, pgx.RowTo[T], sql, args...)
+}
+
+// CollectScalar scans first column of first row into T,
+// returns ErrNoRows if no rows
+func CollectScalar[T any](ctx context.Context, sql string, args ...any) (T, error) {
+	return CollectScalarKey[T](ctx, nil, sql, args...)
+}
+
+// CollectScalarKey likes CollectScalar but uses keyed db or tx
+func CollectScalarKey[T any](ctx context.Context, key any, sql string, args ...any) (T, error) {
+	return collectOneRow(ctx, key, pgx.RowTo[T], sql, args...)
+}
+
+// CollectMap scans first column as key and second column as value of each row into map[K]V,
+// the latter row wins when key duplicated
+func CollectMap[K comparable, V any](ctx context.Context, sql string, args ...any) (map[K]V, error) {
+	return CollectMapKey[K, V](ctx, nil, sql, args...)
+}
+
+// CollectMapKey likes CollectMap but uses keyed db or tx
+func CollectMapKey[K comparable, V any](ctx context.Context, key any, sql string, args ...any) (map[K]V, error) {
+	db, err := q(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, pgsql.MapError(err)
+	}
+	defer rows.Close()
+
+	m := make(map[K]V)
+	for rows.Next() {
+		var (
+			k K
+			v V
+		)
+		err = rows.Scan(&k, &v)
+		if err != nil {
+			return nil, pgsql.MapError(err)
+		}
+		m[k] = v
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, pgsql.MapError(err)
+	}
+	return m, nil
+}
+
+// CollectExists reports whether query returns any row,
+// query should have limit 1 to avoid reading unused rows
+func CollectExists(ctx context.Context, sql string, args ...any) (bool, error) {
+	return CollectExistsKey(ctx, nil, sql, args...)
+}
+
+// CollectExistsKey likes CollectExists but uses keyed db or tx
+func CollectExistsKey(ctx context.Context, key any, sql string, args ...any) (bool, error) {
+	db, err := q(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return false, pgsql.MapError(err)
+	}
+	exists := rows.Next()
+	rows.Close()
+	err = rows.Err()
+	if err != nil {
+		return false, pgsql.MapError(err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,193 @@
+package pgctx_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xkamail/pgsql"
+	"github.com/xkamail/pgsql/pgcode"
+	"github.com/xkamail/pgsql/pgctx"
+)
+
+func TestCollectScalars(t *testing.T) {
+	t.Parallel()
+
+	ctx, mock := newCtx(t)
+
+	mock.ExpectQuery("select id").
+		WithArgs(true).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(1)).AddRow(int64(2)))
+	mock.ExpectQuery("select id").
+		WithArgs(false).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+	xs, err := pgctx.CollectScalars[int64](ctx, "select id from users where active = $1", true)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, xs)
+
+	xs, err = pgctx.CollectScalars[int64](ctx, "select id from users where active = $1", false)
+	assert.NoError(t, err)
+	assert.Empty(t, xs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCollectScalar(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Found", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("select count").
+			WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(int64(3)))
+
+		cnt, err := pgctx.CollectScalar[int64](ctx, "select count(*) from users")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), cnt)
+	})
+
+	t.Run("No rows", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("select name").
+			WillReturnRows(pgxmock.NewRows([]string{"name"}))
+
+		_, err := pgctx.CollectScalar[string](ctx, "select name from users where id = 0")
+		assert.ErrorIs(t, err, pgctx.ErrNoRows)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("In tx", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("select count").
+			WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(int64(1)))
+		mock.ExpectCommit()
+
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			cnt, err := pgctx.CollectScalar[int64](ctx, "select count(*) from users")
+			assert.Equal(t, int64(1), cnt)
+			return err
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCollectMap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Collect", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("select id, name").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).
+				AddRow(int64(1), "a").
+				AddRow(int64(2), "b"))
+
+		m, err := pgctx.CollectMap[int64, string](ctx, "select id, name from users")
+		assert.NoError(t, err)
+		assert.Equal(t, map[int64]string{1: "a", 2: "b"}, m)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("select id, name").
+			WillReturnError(&pgconn.PgError{Code: pgcode.QueryCanceled})
+
+		_, err := pgctx.CollectMap[int64, string](ctx, "select id, name from users")
+		assert.Error(t, err)
+	})
+}
+
+func TestCollectExists(t *testing.T) {
+	t.Parallel()
+
+	ctx, mock := newCtx(t)
+
+	mock.ExpectQuery("select 1").
+		WithArgs("a").
+		WillReturnRows(pgxmock.NewRows([]string{"?column?"}).AddRow(int32(1)))
+	mock.ExpectQuery("select 1").
+		WithArgs("b").
+		WillReturnRows(pgxmock.NewRows([]string{"?column?"}))
+
+	exists, err := pgctx.CollectExists(ctx, "select 1 from users where name = $1 limit 1", "a")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = pgctx.CollectExists(ctx, "select 1 from users where name = $1 limit 1", "b")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCollectKey(t *testing.T) {
+	t.Parallel()
+
+	ctx, mock := newCtx(t)
+	keyMock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	ctx = pgctx.NewKeyContext(ctx, testKey1{}, keyMock)
+
+	keyMock.ExpectQuery("select id").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(1)).AddRow(int64(2)))
+	keyMock.ExpectQuery("select count").
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(int64(2)))
+	keyMock.ExpectQuery("select id, name").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a"))
+	keyMock.ExpectQuery("select 1").
+		WillReturnRows(pgxmock.NewRows([]string{"?column?"}).AddRow(int32(1)))
+
+	xs, err := pgctx.CollectScalarsKey[int64](ctx, testKey1{}, "select id from users")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, xs)
+
+	cnt, err := pgctx.CollectScalarKey[int64](ctx, testKey1{}, "select count(*) from users")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), cnt)
+
+	m, err := pgctx.CollectMapKey[int64, string](ctx, testKey1{}, "select id, name from users")
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]string{1: "a"}, m)
+
+	exists, err := pgctx.CollectExistsKey(ctx, testKey1{}, "select 1 from users limit 1")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, keyMock.ExpectationsWereMet())
+}
+
+func TestCollectJSONB(t *testing.T) {
+	t.Parallel()
+
+	type profile struct {
+		Name string `json:"name"`
+	}
+	type user struct {
+		ID      int64
+		Profile pgsql.JSONB[*profile]
+	}
+
+	ctx, mock := newCtx(t)
+
+	mock.ExpectQuery("select").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "profile"}).
+			AddRow(int64(1), []byte(`{"name": "a"}`)).
+			AddRow(int64(2), nil))
+
+	xs, err := pgctx.CollectByName[user](ctx, "select id, profile from users")
+	assert.NoError(t, err)
+	assert.Equal(t, []*user{
+		{1, pgsql.NewJSONB(&profile{Name: "a"})},
+		{2, pgsql.JSONB[*profile]{}},
+	}, xs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
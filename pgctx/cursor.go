@@ -0,0 +1,164 @@
+package pgctx
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/xkamail/pgsql"
+)
+
+// CursorOptions is the server-side cursor options
+type CursorOptions struct {
+	// Name is the cursor name, default is generated unique name,
+	// it must be a plain identifier (letters, digits, and underscores),
+	// it is not quoted so it is case-insensitive
+	Name string
+
+	// BatchSize is the number of rows per fetch, default is 100.
+	// Set to 1 to update the current row with where current of.
+	BatchSize int
+}
+
+const defaultCursorBatchSize = 100
+
+// ErrInvalidCursorName is the error when CursorOptions.Name is not a plain identifier
+var ErrInvalidCursorName = errors.New("pgctx: invalid cursor name")
+
+var cursorID atomic.Uint64
+
+func (opts *CursorOptions) name() string {
+	if opts != nil && opts.Name != "" {
+		return opts.Name
+	}
+	return "pgctx_cursor_" + strconv.FormatUint(cursorID.Add(1), 10)
+}
+
+func validCursorName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (opts *CursorOptions) batchSize() int {
+	if opts != nil && opts.BatchSize > 0 {
+		return opts.BatchSize
+	}
+	return defaultCursorBatchSize
+}
+
+// CursorSeq returns the sequence that streams rows decoded into *T by column name
+// using server-side cursor, it declares the cursor, fetches rows in batches,
+// and closes the cursor when done, error, or break.
+//
+// The cursor runs in the current tx, or in a new read-only tx if not in tx.
+// The new tx is never retried since rows may already be yielded.
+//
+// To update fetched row, use pgstmt.UpdateStatement.WhereCurrentOf with opts.Name
+// and opts.BatchSize 1 inside a read-write tx with "select ... for update".
+func CursorSeq[T any](ctx context.Context, opts *CursorOptions, sql string, args ...any) iter.Seq2[*T, error] {
+	return CursorSeqKey[T](ctx, nil, opts, sql, args...)
+}
+
+// CursorSeqKey likes CursorSeq but uses keyed db or tx
+func CursorSeqKey[T any](ctx context.Context, key any, opts *CursorOptions, sql string, args ...any) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		stopped := false
+		f := func(ctx context.Context) error {
+			return iterCursor(ctx, key, opts, sql, args, func(x *T) bool {
+				stopped = !yield(x, nil)
+				return !stopped
+			})
+		}
+
+		var err error
+		if IsInTxKey(ctx, key) {
+			err = f(ctx)
+		} else {
+			var txOpts TxOptions
+			txOpts.AccessMode = pgx.ReadOnly
+			txOpts.MaxAttempts = 1
+			err = runInTxOptions(ctx, key, &txOpts, f)
+		}
+		if err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
+}
+
+// EachCursor calls fn for each row decoded into *T by column name using server-side cursor,
+// see CursorSeq for more info
+func EachCursor[T any](ctx context.Context, opts *CursorOptions, fn func(x *T) error, sql string, args ...any) error {
+	return EachCursorKey[T](ctx, nil, opts, fn, sql, args...)
+}
+
+// EachCursorKey likes EachCursor but uses keyed db or tx
+func EachCursorKey[T any](ctx context.Context, key any, opts *CursorOptions, fn func(x *T) error, sql string, args ...any) error {
+	var err error
+	CursorSeqKey[T](ctx, key, opts, sql, args...)(func(x *T, e error) bool {
+		if e != nil {
+			err = e
+			return false
+		}
+		err = fn(x)
+		return err == nil
+	})
+	return err
+}
+
+// iterCursor iterates cursor until f returns false, ctx must be in keyed tx.
+// Each batch is buffered and its rows closed before f is called,
+// so f can run queries on the same tx, ex. update where current of.
+func iterCursor[T any](ctx context.Context, key any, opts *CursorOptions, sql string, args []any, f func(x *T) bool) error {
+	db, err := q(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	name := opts.name()
+	if !validCursorName(name) {
+		return ErrInvalidCursorName
+	}
+	_, err = db.Exec(ctx, "declare "+name+" no scroll cursor for "+sql, args...)
+	if err != nil {
+		return pgsql.MapError(err)
+	}
+	// close error is ignored, the cursor is closed when tx ended
+	defer db.Exec(ctx, "close "+name)
+
+	batchSize := opts.batchSize()
+	fetch := "fetch " + strconv.Itoa(batchSize) + " from " + name
+	rowTo := pgsql.RowToAddrOfStructByName[T](nil)
+	for {
+		rows, err := db.Query(ctx, fetch)
+		if err != nil {
+			return pgsql.MapError(err)
+		}
+		xs, err := pgx.CollectRows(rows, rowTo)
+		if err != nil {
+			return pgsql.MapError(err)
+		}
+
+		for _, x := range xs {
+			if !f(x) {
+				return nil
+			}
+		}
+		if len(xs) < batchSize {
+			return nil
+		}
+	}
+}
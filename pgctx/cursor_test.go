@@ -0,0 +1,241 @@
+package pgctx_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xkamail/pgsql/pgctx"
+	"github.com/xkamail/pgsql/pgstmt"
+)
+
+func TestCursor(t *testing.T) {
+	t.Parallel()
+
+	opts := &pgctx.CursorOptions{Name: "c", BatchSize: 2}
+
+	t.Run("Auto tx", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		mock.ExpectExec("declare c no scroll cursor for select id, name from users where id > \\$1").
+			WithArgs(0).
+			WillReturnResult(pgxmock.NewResult("DECLARE CURSOR", 0))
+		mock.ExpectQuery("fetch 2 from c").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a").AddRow(int64(2), "b"))
+		mock.ExpectQuery("fetch 2 from c").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(int64(3), "c"))
+		mock.ExpectExec("close c").WillReturnResult(pgxmock.NewResult("CLOSE CURSOR", 0))
+		mock.ExpectCommit()
+
+		var ids []int64
+		err := pgctx.EachCursor(ctx, opts, func(x *iterUser) error {
+			ids = append(ids, x.ID)
+			return nil
+		}, "select id, name from users where id > $1", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 2, 3}, ids)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Exact batch", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		mock.ExpectExec("declare c").WillReturnResult(pgxmock.NewResult("DECLARE CURSOR", 0))
+		mock.ExpectQuery("fetch 2 from c").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a").AddRow(int64(2), "b"))
+		mock.ExpectQuery("fetch 2 from c").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}))
+		mock.ExpectExec("close c").WillReturnResult(pgxmock.NewResult("CLOSE CURSOR", 0))
+		mock.ExpectCommit()
+
+		cnt := 0
+		err := pgctx.EachCursor(ctx, opts, func(x *iterUser) error {
+			cnt++
+			return nil
+		}, "select id, name from users")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, cnt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Break", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		mock.ExpectExec("declare c").WillReturnResult(pgxmock.NewResult("DECLARE CURSOR", 0))
+		mock.ExpectQuery("fetch 2 from c").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a").AddRow(int64(2), "b")).
+			RowsWillBeClosed()
+		mock.ExpectExec("close c").WillReturnResult(pgxmock.NewResult("CLOSE CURSOR", 0))
+		mock.ExpectCommit()
+
+		cnt := 0
+		for _, err := range pgctx.CursorSeq[iterUser](ctx, opts, "select id, name from users") {
+			assert.NoError(t, err)
+			cnt++
+			break
+		}
+		assert.Equal(t, 1, cnt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		mock.ExpectExec("declare c").WillReturnResult(pgxmock.NewResult("DECLARE CURSOR", 0))
+		mock.ExpectQuery("fetch 2 from c").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a").AddRow(int64(2), "b"))
+		mock.ExpectExec("close c").WillReturnResult(pgxmock.NewResult("CLOSE CURSOR", 0))
+		mock.ExpectRollback()
+
+		fnErr := errors.New("fn error")
+		err := pgctx.EachCursor(ctx, opts, func(x *iterUser) error {
+			return fnErr
+		}, "select id, name from users")
+		assert.Equal(t, fnErr, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("In tx", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("declare pgctx_cursor_\\d+ no scroll cursor for select").
+			WillReturnResult(pgxmock.NewResult("DECLARE CURSOR", 0))
+		mock.ExpectQuery("fetch 100 from pgctx_cursor_\\d+").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a"))
+		mock.ExpectExec("close pgctx_cursor_\\d+").WillReturnResult(pgxmock.NewResult("CLOSE CURSOR", 0))
+		mock.ExpectCommit()
+
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			return pgctx.EachCursor(ctx, nil, func(x *iterUser) error {
+				return nil
+			}, "select id, name from users")
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Query in callback", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+		var tr openTracer
+		ctx = pgctx.NewTracerContext(ctx, &tr)
+
+		opts := &pgctx.CursorOptions{Name: "MyCursor", BatchSize: 1}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`declare MyCursor no scroll cursor for select id, name from users for update`).
+			WillReturnResult(pgxmock.NewResult("DECLARE CURSOR", 0))
+		for _, name := range []string{"a", "b"} {
+			mock.ExpectQuery(`fetch 1 from MyCursor`).
+				WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(int64(1), name))
+			mock.ExpectExec(`update users set name = \$1 where current of MyCursor`).
+				WithArgs("x").
+				WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		}
+		mock.ExpectQuery(`fetch 1 from MyCursor`).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}))
+		mock.ExpectExec(`close MyCursor`).WillReturnResult(pgxmock.NewResult("CLOSE CURSOR", 0))
+		mock.ExpectCommit()
+
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			return pgctx.EachCursor(ctx, opts, func(x *iterUser) error {
+				// fetch rows must be closed, or the conn is busy
+				assert.Zero(t, tr.open)
+
+				q, args := pgstmt.Update(func(b pgstmt.UpdateStatement) {
+					b.Table("users")
+					b.Set("name").To("x")
+					b.WhereCurrentOf(opts.Name)
+				}).SQL()
+				_, err := pgctx.Exec(ctx, q, args...)
+				return err
+			}, "select id, name from users for update")
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid name", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		for _, name := range []string{"c; drop table users", `"c"`, "1c"} {
+			mock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+			mock.ExpectRollback()
+
+			err := pgctx.EachCursor(ctx, &pgctx.CursorOptions{Name: name}, func(x *iterUser) error {
+				return nil
+			}, "select id, name from users")
+			assert.ErrorIs(t, err, pgctx.ErrInvalidCursorName)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCursorKey(t *testing.T) {
+	t.Parallel()
+
+	ctx, mock := newCtx(t)
+	keyMock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	ctx = pgctx.NewKeyContext(ctx, testKey1{}, keyMock)
+
+	keyMock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	keyMock.ExpectExec("declare c no scroll cursor for select id, name from users").
+		WillReturnResult(pgxmock.NewResult("DECLARE CURSOR", 0))
+	keyMock.ExpectQuery("fetch 100 from c").WillReturnRows(newIterRows())
+	keyMock.ExpectExec("close c").WillReturnResult(pgxmock.NewResult("CLOSE CURSOR", 0))
+	keyMock.ExpectCommit()
+
+	n := 0
+	err = pgctx.EachCursorKey(ctx, testKey1{}, &pgctx.CursorOptions{Name: "c"}, func(x *iterUser) error {
+		n++
+		return nil
+	}, "select id, name from users")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	// in keyed tx, cursor does not begin new tx
+	keyMock.ExpectBegin()
+	keyMock.ExpectExec("declare c").WillReturnResult(pgxmock.NewResult("DECLARE CURSOR", 0))
+	keyMock.ExpectQuery("fetch 100 from c").WillReturnRows(newIterRows())
+	keyMock.ExpectExec("close c").WillReturnResult(pgxmock.NewResult("CLOSE CURSOR", 0))
+	keyMock.ExpectCommit()
+
+	err = pgctx.RunInTxKey(ctx, testKey1{}, func(ctx context.Context) error {
+		var xs []*iterUser
+		for x, err := range pgctx.CursorSeqKey[iterUser](ctx, testKey1{}, &pgctx.CursorOptions{Name: "c"}, "select id, name from users") {
+			if err != nil {
+				return err
+			}
+			xs = append(xs, x)
+		}
+		assert.Len(t, xs, 3)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, keyMock.ExpectationsWereMet())
+}
+
+// openTracer counts queries that are not ended
+type openTracer struct {
+	open int
+}
+
+func (t *openTracer) TraceQueryStart(ctx context.Context, data pgctx.TraceQueryStartData) context.Context {
+	t.open++
+	return ctx
+}
+
+func (t *openTracer) TraceQueryEnd(ctx context.Context, data pgctx.TraceQueryEndData) {
+	t.open--
+}
@@ -0,0 +1,77 @@
+package pgctx
+
+import (
+	"context"
+	"iter"
+
+	"github.com/xkamail/pgsql"
+)
+
+// Seq returns the sequence that streams rows decoded into *T by column name,
+// query runs when the sequence is iterated.
+//
+// Error is yielded as the last pair with nil *T,
+// break closes rows.
+//
+//	for x, err := range pgctx.Seq[User](ctx, "select id, name from users") {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func Seq[T any](ctx context.Context, sql string, args ...any) iter.Seq2[*T, error] {
+	return SeqKey[T](ctx, nil, sql, args...)
+}
+
+// SeqKey likes Seq but uses keyed db or tx
+func SeqKey[T any](ctx context.Context, key any, sql string, args ...any) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		db, err := q(ctx, key)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		rows, err := db.Query(ctx, sql, args...)
+		if err != nil {
+			yield(nil, pgsql.MapError(err))
+			return
+		}
+		defer rows.Close()
+
+		rowTo := pgsql.RowToAddrOfStructByName[T](nil)
+		for rows.Next() {
+			x, err := rowTo(rows)
+			if err != nil {
+				yield(nil, pgsql.MapError(err))
+				return
+			}
+			if !yield(x, nil) {
+				return
+			}
+		}
+		err = rows.Err()
+		if err != nil {
+			yield(nil, pgsql.MapError(err))
+		}
+	}
+}
+
+// Each calls fn for each row decoded into *T by column name without buffering,
+// error from fn stops the iteration and returned as is
+func Each[T any](ctx context.Context, fn func(x *T) error, sql string, args ...any) error {
+	return EachKey[T](ctx, nil, fn, sql, args...)
+}
+
+// EachKey likes Each but uses keyed db or tx
+func EachKey[T any](ctx context.Context, key any, fn func(x *T) error, sql string, args ...any) error {
+	var err error
+	SeqKey[T](ctx, key, sql, args...)(func(x *T, e error) bool {
+		if e != nil {
+			err = e
+			return false
+		}
+		err = fn(x)
+		return err == nil
+	})
+	return err
+}
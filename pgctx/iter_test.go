@@ -0,0 +1,177 @@
+package pgctx_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xkamail/pgsql/pgctx"
+)
+
+type iterUser struct {
+	ID   int64
+	Name string
+}
+
+func newIterRows() *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"id", "name"}).
+		AddRow(int64(1), "a").
+		AddRow(int64(2), "b").
+		AddRow(int64(3), "c")
+}
+
+func TestSeq(t *testing.T) {
+	t.Parallel()
+
+	t.Run("All", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("select").WillReturnRows(newIterRows()).RowsWillBeClosed()
+
+		var xs []*iterUser
+		for x, err := range pgctx.Seq[iterUser](ctx, "select id, name from users") {
+			assert.NoError(t, err)
+			xs = append(xs, x)
+		}
+		assert.Equal(t, []*iterUser{{1, "a"}, {2, "b"}, {3, "c"}}, xs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Break", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("select").WillReturnRows(newIterRows()).RowsWillBeClosed()
+
+		var xs []*iterUser
+		for x, err := range pgctx.Seq[iterUser](ctx, "select id, name from users") {
+			assert.NoError(t, err)
+			xs = append(xs, x)
+			if len(xs) == 2 {
+				break
+			}
+		}
+		assert.Equal(t, []*iterUser{{1, "a"}, {2, "b"}}, xs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rows error", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		rowErr := errors.New("row error")
+		mock.ExpectQuery("select").WillReturnRows(newIterRows().RowError(1, rowErr))
+
+		var (
+			xs   []*iterUser
+			errs []error
+		)
+		for x, err := range pgctx.Seq[iterUser](ctx, "select id, name from users") {
+			if err != nil {
+				errs = append(errs, err)
+				assert.Nil(t, x)
+				continue
+			}
+			xs = append(xs, x)
+		}
+		assert.Len(t, xs, 1)
+		if assert.Len(t, errs, 1) {
+			assert.ErrorIs(t, errs[0], rowErr)
+		}
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("select").WillReturnError(assert.AnError)
+
+		cnt := 0
+		for _, err := range pgctx.Seq[iterUser](ctx, "select id, name from users") {
+			cnt++
+			assert.ErrorIs(t, err, assert.AnError)
+		}
+		assert.Equal(t, 1, cnt)
+	})
+}
+
+func TestEach(t *testing.T) {
+	t.Parallel()
+
+	t.Run("All", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("select").WillReturnRows(newIterRows()).RowsWillBeClosed()
+
+		var ids []int64
+		err := pgctx.Each(ctx, func(x *iterUser) error {
+			ids = append(ids, x.ID)
+			return nil
+		}, "select id, name from users")
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 2, 3}, ids)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("select").WillReturnRows(newIterRows()).RowsWillBeClosed()
+
+		stop := errors.New("stop")
+		cnt := 0
+		err := pgctx.Each(ctx, func(x *iterUser) error {
+			cnt++
+			return stop
+		}, "select id, name from users")
+		assert.Equal(t, stop, err)
+		assert.Equal(t, 1, cnt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("In tx", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("select").WillReturnRows(newIterRows())
+		mock.ExpectCommit()
+
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			return pgctx.Each(ctx, func(x *iterUser) error {
+				return nil
+			}, "select id, name from users")
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSeqKey(t *testing.T) {
+	t.Parallel()
+
+	ctx, mock := newCtx(t)
+	keyMock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	ctx = pgctx.NewKeyContext(ctx, testKey1{}, keyMock)
+
+	keyMock.ExpectQuery("select").WillReturnRows(newIterRows()).RowsWillBeClosed()
+	keyMock.ExpectQuery("select").WillReturnRows(newIterRows()).RowsWillBeClosed()
+
+	var xs []*iterUser
+	for x, err := range pgctx.SeqKey[iterUser](ctx, testKey1{}, "select id, name from users") {
+		assert.NoError(t, err)
+		xs = append(xs, x)
+	}
+	assert.Len(t, xs, 3)
+
+	n := 0
+	err = pgctx.EachKey(ctx, testKey1{}, func(x *iterUser) error {
+		n++
+		return nil
+	}, "select id, name from users")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, keyMock.ExpectationsWereMet())
+}
@@ -46,176 +46,270 @@ func Middleware(db DB) func(h http.Handler) http.Handler {
 	return KeyMiddleware(nil, db)
 }
 
-// With creates new empty key context with db from keyed context
+// With creates new empty key context with db and tx from keyed context
 func With(ctx context.Context, key any) context.Context {
 	db := ctx.Value(ctxKeyDB{key})
-	return context.WithValue(ctx, ctxKeyDB{}, db)
+	ctx = context.WithValue(ctx, ctxKeyDB{}, db)
+	return context.WithValue(ctx, ctxKeyQueryer{}, ctx.Value(ctxKeyQueryer{key}))
 }
 
+// Errors
+var (
+	ErrNoDB    = errors.New("pgctx: no db in context")
+	ErrNotInTx = errors.New("pgctx: not in tx")
+)
+
+// GetDB returns db from context, panic if no db
 func GetDB(ctx context.Context) DB {
 	return ctx.Value(ctxKeyDB{}).(DB)
 }
 
+// GetDBKey returns keyed db from context, panic if no db
 func GetDBKey(ctx context.Context, key any) DB {
 	return ctx.Value(ctxKeyDB{key}).(DB)
 }
 
+// GetTx returns tx from context, panic if not in tx
 func GetTx(ctx context.Context) pgx.Tx {
-	return ctx.Value(ctxKeyQueryer{}).(*wrapTx).Tx // panic if not in tx
+	return GetTxKey(ctx, nil)
+}
+
+// GetTxKey returns keyed tx from context, panic if not in tx
+func GetTxKey(ctx context.Context, key any) pgx.Tx {
+	return ctx.Value(ctxKeyQueryer{key}).(*wrapTx).Tx // panic if not in tx
 }
 
-type wrapTx struct {
-	pgx.Tx
-	onCommitted []func(ctx context.Context)
+// LookupDB returns db from context, or ErrNoDB if no db
+func LookupDB(ctx context.Context) (DB, error) {
+	return LookupDBKey(ctx, nil)
 }
 
-var _ Queryer = &wrapTx{}
+// LookupDBKey returns keyed db from context, or ErrNoDB if no db
+func LookupDBKey(ctx context.Context, key any) (DB, error) {
+	db, ok := ctx.Value(ctxKeyDB{key}).(DB)
+	if !ok {
+		return nil, ErrNoDB
+	}
+	return db, nil
+}
 
-func RunTx[R any](ctx context.Context, f func(ctx context.Context) (*R, error)) (*R, error) {
-	return BeginTxOption(ctx, nil, f)
+// LookupTx returns tx from context, or ErrNotInTx if not in tx
+func LookupTx(ctx context.Context) (pgx.Tx, error) {
+	return LookupTxKey(ctx, nil)
 }
 
-// BeginTxOption is a shortcut function that runs f in a transaction.
-// and returns its result.
-func BeginTxOption[R any](ctx context.Context, opt *pgsql.TxOptions, f func(ctx context.Context) (*R, error)) (*R, error) {
-	if IsInTx(ctx) {
-		return f(ctx)
+// LookupTxKey returns keyed tx from context, or ErrNotInTx if not in tx
+func LookupTxKey(ctx context.Context, key any) (pgx.Tx, error) {
+	pTx, ok := getTx(ctx, key)
+	if !ok {
+		return nil, ErrNotInTx
 	}
+	return pTx.Tx, nil
+}
 
-	db := ctx.Value(ctxKeyDB{}).(pgsql.BeginTxer)
-	var pTx wrapTx
-	abort := false
-	var result *R
-	err := pgsql.RunInTxContext(ctx, db, opt, func(tx pgx.Tx) error {
-		pTx = wrapTx{Tx: tx}
-		ctx := context.WithValue(ctx, ctxKeyQueryer{}, &pTx)
-		r, err := f(ctx)
-		result = r
-		if errors.Is(err, pgsql.ErrAbortTx) {
-			abort = true
-		}
-		return err
-	})
-	if err != nil {
-		return nil, err
+type (
+	ctxKeyDB struct {
+		key any
+	}
+	ctxKeyQueryer struct {
+		key any
 	}
-	if !abort && len(pTx.onCommitted) > 0 {
-		for _, f := range pTx.onCommitted {
-			f(ctx)
+)
+
+func getTx(ctx context.Context, key any) (*wrapTx, bool) {
+	pTx, ok := ctx.Value(ctxKeyQueryer{key}).(*wrapTx)
+	return pTx, ok
+}
+
+func q(ctx context.Context, key any) (Queryer, error) {
+	var db Queryer
+	pTx, inTx := getTx(ctx, key)
+	if inTx {
+		db = pTx
+	} else {
+		var err error
+		db, err = LookupDBKey(ctx, key)
+		if err != nil {
+			return nil, err
 		}
 	}
-	return result, nil
+
+	if t, ok := ctx.Value(ctxKeyTracer{}).(Tracer); ok {
+		return &traceQueryer{Queryer: db, tracer: t, inTx: inTx}, nil
+	}
+	return db, nil
+}
+
+// QueryRow calls db.QueryRowContext
+func QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
+	return QueryRowKey(ctx, nil, query, args...)
 }
 
-// RunInTxOptions starts sql tx if not started
-func RunInTxOptions(ctx context.Context, opt *pgsql.TxOptions, f func(ctx context.Context) error) error {
-	if IsInTx(ctx) {
-		return f(ctx)
+// QueryRowKey calls QueryRow on keyed db or tx
+func QueryRowKey(ctx context.Context, key any, query string, args ...any) pgx.Row {
+	db, err := q(ctx, key)
+	if err != nil {
+		return errRow{err}
 	}
+	return &mapRow{db.QueryRow(ctx, query, args...)}
+}
 
-	db := ctx.Value(ctxKeyDB{}).(pgsql.BeginTxer)
-	var pTx wrapTx
-	abort := false
-	err := pgsql.RunInTxContext(ctx, db, opt, func(tx pgx.Tx) error {
-		pTx = wrapTx{Tx: tx}
-		ctx := context.WithValue(ctx, ctxKeyQueryer{}, &pTx)
-		err := f(ctx)
-		if errors.Is(err, pgsql.ErrAbortTx) {
-			abort = true
-		}
-		return err
-	})
+// Query calls db.QueryContext
+func Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	return QueryKey(ctx, nil, query, args...)
+}
+
+// QueryKey calls Query on keyed db or tx
+func QueryKey(ctx context.Context, key any, query string, args ...any) (pgx.Rows, error) {
+	db, err := q(ctx, key)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if !abort && len(pTx.onCommitted) > 0 {
-		for _, f := range pTx.onCommitted {
-			f(ctx)
-		}
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, pgsql.MapError(err)
 	}
-	return nil
+	return &mapRows{rows}, nil
 }
 
-// RunInTx calls RunInTxOptions with default options
-func RunInTx(ctx context.Context, f func(ctx context.Context) error) error {
-	return RunInTxOptions(ctx, nil, f)
+// Exec calls db.ExecContext
+func Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	return ExecKey(ctx, nil, query, args...)
 }
 
-// RunInReadOnlyTx calls RunInTxOptions with read only options
-func RunInReadOnlyTx(ctx context.Context, f func(ctx context.Context) error) error {
-	var opts pgsql.TxOptions
-	opts.AccessMode = pgx.ReadOnly
-	return RunInTxOptions(ctx, &opts, f)
+// ExecKey calls Exec on keyed db or tx
+func ExecKey(ctx context.Context, key any, query string, args ...any) (pgconn.CommandTag, error) {
+	db, err := q(ctx, key)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	tag, err := db.Exec(ctx, query, args...)
+	return tag, pgsql.MapError(err)
 }
 
-// IsInTx checks is context inside RunInTx
-func IsInTx(ctx context.Context) bool {
-	_, ok := ctx.Value(ctxKeyQueryer{}).(*wrapTx)
-	return ok
+// Iter calls pgsql.IterContext
+func Iter(ctx context.Context, iter pgsql.Iterator, query string, args ...any) error {
+	return IterKey(ctx, nil, iter, query, args...)
 }
 
-// Committed calls f after committed or immediate if not in tx
-func Committed(ctx context.Context, f func(ctx context.Context)) {
-	if f == nil {
-		return
+// IterKey calls pgsql.IterContext on keyed db or tx
+func IterKey(ctx context.Context, key any, iter pgsql.Iterator, query string, args ...any) error {
+	db, err := q(ctx, key)
+	if err != nil {
+		return err
 	}
+	return pgsql.MapError(pgsql.IterContext(ctx, db, iter, query, args...))
+}
 
-	if !IsInTx(ctx) {
-		f(ctx)
-		return
-	}
+// Collect scans rows into []*T by position,
+// see CollectByName to scan by column name
+func Collect[T any](ctx context.Context, sql string, args ...any) ([]*T, error) {
+	return CollectKey[T](ctx, nil, sql, args...)
+}
 
-	pTx := ctx.Value(ctxKeyQueryer{}).(*wrapTx)
-	pTx.onCommitted = append(pTx.onCommitted, f)
+func CollectKey[T any](ctx context.Context, key any, sql string, args ...any) ([]*T, error) {
+	return collectRows(ctx, key, pgx.RowToAddrOfStructByPos[T], sql, args...)
 }
 
-type (
-	ctxKeyDB struct {
-		key any
-	}
-	ctxKeyQueryer struct{}
-)
+// CollectOne scans a row into *T by position,
+// see CollectOneByName to scan by column name
+func CollectOne[T any](ctx context.Context, sql string, args ...any) (*T, error) {
+	return CollectOneKey[T](ctx, nil, sql, args...)
+}
 
-func q(ctx context.Context) Queryer {
-	if q, ok := ctx.Value(ctxKeyQueryer{}).(Queryer); ok {
-		return q
-	}
+func CollectOneKey[T any](ctx context.Context, key any, sql string, args ...any) (*T, error) {
+	return collectOneRow(ctx, key, pgx.RowToAddrOfStructByPos[T], sql, args...)
+}
 
-	return ctx.Value(ctxKeyDB{}).(Queryer)
+// CollectByName scans rows into []*T by column name using pgsql.DefaultStructMapper
+func CollectByName[T any](ctx context.Context, sql string, args ...any) ([]*T, error) {
+	return CollectByMapperKey[T](ctx, nil, nil, sql, args...)
 }
 
-// QueryRow calls db.QueryRowContext
-func QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
-	return q(ctx).QueryRow(ctx, query, args...)
+func CollectByNameKey[T any](ctx context.Context, key any, sql string, args ...any) ([]*T, error) {
+	return CollectByMapperKey[T](ctx, key, nil, sql, args...)
 }
 
-// Query calls db.QueryContext
-func Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
-	return q(ctx).Query(ctx, query, args...)
+// CollectOneByName scans a row into *T by column name using pgsql.DefaultStructMapper
+func CollectOneByName[T any](ctx context.Context, sql string, args ...any) (*T, error) {
+	return CollectOneByMapperKey[T](ctx, nil, nil, sql, args...)
 }
 
-// Exec calls db.ExecContext
-func Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
-	return q(ctx).Exec(ctx, query, args...)
+func CollectOneByNameKey[T any](ctx context.Context, key any, sql string, args ...any) (*T, error) {
+	return CollectOneByMapperKey[T](ctx, key, nil, sql, args...)
 }
 
-// Iter calls pgsql.IterContext
-func Iter(ctx context.Context, iter pgsql.Iterator, query string, args ...any) error {
-	return pgsql.IterContext(ctx, q(ctx), iter, query, args...)
+// CollectByMapper likes CollectByName but uses m, ex. strict mode or custom naming,
+// nil m will use pgsql.DefaultStructMapper
+func CollectByMapper[T any](ctx context.Context, m *pgsql.StructMapper, sql string, args ...any) ([]*T, error) {
+	return CollectByMapperKey[T](ctx, nil, m, sql, args...)
 }
 
-func Collect[T any](ctx context.Context, sql string, args ...any) ([]*T, error) {
-	rows, err := q(ctx).Query(ctx, sql, args...)
+func CollectByMapperKey[T any](ctx context.Context, key any, m *pgsql.StructMapper, sql string, args ...any) ([]*T, error) {
+	return collectRows(ctx, key, pgsql.RowToAddrOfStructByName[T](m), sql, args...)
+}
+
+// CollectOneByMapper likes CollectOneByName but uses m,
+// nil m will use pgsql.DefaultStructMapper
+func CollectOneByMapper[T any](ctx context.Context, m *pgsql.StructMapper, sql string, args ...any) (*T, error) {
+	return CollectOneByMapperKey[T](ctx, nil, m, sql, args...)
+}
+
+func CollectOneByMapperKey[T any](ctx context.Context, key any, m *pgsql.StructMapper, sql string, args ...any) (*T, error) {
+	return collectOneRow(ctx, key, pgsql.RowToAddrOfStructByName[T](m), sql, args...)
+}
+
+func collectRows[T any](ctx context.Context, key any, fn pgx.RowToFunc[T], sql string, args ...any) ([]T, error) {
+	db, err := q(ctx, key)
 	if err != nil {
 		return nil, err
 	}
-	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByPos[T])
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, pgsql.MapError(err)
+	}
+	xs, err := pgx.CollectRows(rows, fn)
+	return xs, pgsql.MapError(err)
 }
 
-func CollectOne[T any](ctx context.Context, sql string, args ...any) (*T, error) {
-	rows, err := q(ctx).Query(ctx, sql, args...)
+func collectOneRow[T any](ctx context.Context, key any, fn pgx.RowToFunc[T], sql string, args ...any) (T, error) {
+	db, err := q(ctx, key)
 	if err != nil {
-		return nil, err
+		var zero T
+		return zero, err
 	}
-	return pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByPos[T])
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		var zero T
+		return zero, pgsql.MapError(err)
+	}
+	x, err := pgx.CollectOneRow(rows, fn)
+	return x, pgsql.MapError(err)
+}
+
+// errRow is the row that always returns err
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...any) error {
+	return r.err
+}
+
+// mapRow maps scan error with pgsql.MapError
+type mapRow struct {
+	pgx.Row
+}
+
+func (r *mapRow) Scan(dest ...any) error {
+	return pgsql.MapError(r.Row.Scan(dest...))
+}
+
+// mapRows maps rows error with pgsql.MapError
+type mapRows struct {
+	pgx.Rows
+}
+
+func (r *mapRows) Err() error {
+	return pgsql.MapError(r.Rows.Err())
 }
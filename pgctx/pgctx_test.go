@@ -2,15 +2,21 @@ package pgctx_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/xkamail/pgsql"
+	"github.com/xkamail/pgsql/pgcode"
 	"github.com/xkamail/pgsql/pgctx"
 )
 
@@ -91,8 +97,10 @@ func TestKeyMiddleware(t *testing.T) {
 		assert.NotPanics(t, func() {
 			pgctx.Exec(pgctx.With(ctx, testKey1{}), "select 1")
 		})
-		assert.Panics(t, func() {
-			pgctx.QueryRow(ctx, "select 1")
+		assert.NotPanics(t, func() {
+			var x int
+			err := pgctx.QueryRow(ctx, "select 1").Scan(&x)
+			assert.ErrorIs(t, err, pgctx.ErrNoDB)
 		})
 	})).ServeHTTP(w, r)
 	assert.True(t, called)
@@ -270,3 +278,821 @@ func TestCommitted(t *testing.T) {
 	})
 
 }
+
+var errTaken = errors.New("pgctx email taken")
+
+func init() {
+	pgsql.RegisterError(pgcode.UniqueViolation, "pgctx_test_email_key", errTaken)
+}
+
+func TestMapError(t *testing.T) {
+	t.Parallel()
+
+	pgErr := &pgconn.PgError{Code: "23505", ConstraintName: "pgctx_test_email_key"}
+
+	t.Run("Exec", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectExec("insert").WithArgs("a@b").WillReturnError(pgErr)
+		_, err := pgctx.Exec(ctx, "insert into users (email) values ($1)", "a@b")
+		assert.ErrorIs(t, err, errTaken)
+		assert.ErrorIs(t, err, pgErr)
+	})
+
+	t.Run("QueryRow", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("insert").WithArgs("a@b").WillReturnError(pgErr)
+		var id int
+		err := pgctx.QueryRow(ctx, "insert into users (email) values ($1) returning id", "a@b").Scan(&id)
+		assert.ErrorIs(t, err, errTaken)
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("insert").WithArgs("a@b").WillReturnError(pgErr)
+		_, err := pgctx.Query(ctx, "insert into users (email) values ($1) returning id", "a@b")
+		assert.ErrorIs(t, err, errTaken)
+	})
+
+	t.Run("RunInTx", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			return pgErr
+		})
+		assert.ErrorIs(t, err, errTaken)
+	})
+}
+
+func TestRunInSavepoint(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Outside Tx", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		called := false
+		err := pgctx.RunInSavepoint(ctx, func(ctx context.Context) error {
+			called = true
+			assert.True(t, pgctx.IsInTx(ctx))
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, called)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		var calls []string
+		mock.ExpectBegin()
+		mock.ExpectExec("savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+		mock.ExpectExec("release savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("RELEASE", 0))
+		mock.ExpectCommit()
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			pgctx.Committed(ctx, func(ctx context.Context) {
+				calls = append(calls, "outer")
+			})
+			err := pgctx.RunInSavepoint(ctx, func(ctx context.Context) error {
+				pgctx.Committed(ctx, func(ctx context.Context) {
+					calls = append(calls, "inner")
+				})
+				return nil
+			})
+			assert.Empty(t, calls)
+			return err
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"outer", "inner"}, calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+		mock.ExpectExec("rollback to savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("ROLLBACK", 0))
+		mock.ExpectCommit()
+		retErr := fmt.Errorf("error")
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			err := pgctx.RunInSavepoint(ctx, func(ctx context.Context) error {
+				pgctx.Committed(ctx, func(ctx context.Context) {
+					assert.Fail(t, "should not be called")
+				})
+				return retErr
+			})
+			assert.Equal(t, retErr, err)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Abort Tx", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+		mock.ExpectExec("rollback to savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("ROLLBACK", 0))
+		mock.ExpectCommit()
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			return pgctx.RunInSavepoint(ctx, func(ctx context.Context) error {
+				return pgsql.ErrAbortTx
+			})
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback Error", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		rbErr := &pgconn.PgError{Code: "23505", ConstraintName: "pgctx_test_email_key"}
+		mock.ExpectBegin()
+		mock.ExpectExec("savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+		mock.ExpectExec("rollback to savepoint pgctx_sp_1").WillReturnError(rbErr)
+		mock.ExpectRollback()
+		retErr := fmt.Errorf("error")
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			return pgctx.RunInSavepoint(ctx, func(ctx context.Context) error {
+				return retErr
+			})
+		})
+		assert.ErrorIs(t, err, retErr)
+		assert.ErrorIs(t, err, rbErr)
+		assert.ErrorIs(t, err, errTaken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Release Error", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+		mock.ExpectExec("release savepoint pgctx_sp_1").
+			WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "pgctx_test_email_key"})
+		mock.ExpectRollback()
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			return pgctx.RunInSavepoint(ctx, func(ctx context.Context) error {
+				return nil
+			})
+		})
+		assert.ErrorIs(t, err, errTaken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+		mock.ExpectExec("savepoint pgctx_sp_2").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+		mock.ExpectExec("rollback to savepoint pgctx_sp_2").WillReturnResult(pgxmock.NewResult("ROLLBACK", 0))
+		mock.ExpectExec("release savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("RELEASE", 0))
+		mock.ExpectCommit()
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			return pgctx.RunInSavepoint(ctx, func(ctx context.Context) error {
+				return pgctx.RunInSavepoint(ctx, func(ctx context.Context) error {
+					return pgsql.ErrAbortTx
+				})
+			})
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRolledBack(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Outside Tx", func(t *testing.T) {
+		ctx, _ := newCtx(t)
+		pgctx.RolledBack(ctx, func(ctx context.Context) {
+			assert.Fail(t, "should not be called")
+		})
+		pgctx.RolledBack(ctx, nil)
+	})
+
+	t.Run("Committed", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			pgctx.RolledBack(ctx, func(ctx context.Context) {
+				assert.Fail(t, "should not be called")
+			})
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		var calls []string
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			pgctx.RolledBack(ctx, func(ctx context.Context) {
+				calls = append(calls, "1")
+			})
+			pgctx.RolledBack(ctx, func(ctx context.Context) {
+				calls = append(calls, "2")
+			})
+			return fmt.Errorf("error")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, []string{"1", "2"}, calls)
+	})
+
+	t.Run("Abort Tx", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		called := false
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		_, err := pgctx.RunTx(ctx, func(ctx context.Context) (*int, error) {
+			pgctx.RolledBack(ctx, func(ctx context.Context) {
+				called = true
+			})
+			return nil, pgsql.ErrAbortTx
+		})
+		assert.NoError(t, err)
+		assert.True(t, called)
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		called := false
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		assert.Panics(t, func() {
+			pgctx.RunInTx(ctx, func(ctx context.Context) error {
+				pgctx.RolledBack(ctx, func(ctx context.Context) {
+					called = true
+				})
+				panic("panic")
+			})
+		})
+		assert.True(t, called)
+	})
+
+	t.Run("Exhausted Retries", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		for i := 0; i < 2; i++ {
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+		}
+		var calls []int
+		err := pgctx.RunInTxOptions(ctx, &pgsql.TxOptions{MaxAttempts: 2}, func(ctx context.Context) error {
+			attempt := pgsql.Attempt(ctx)
+			pgctx.RolledBack(ctx, func(ctx context.Context) {
+				calls = append(calls, attempt)
+			})
+			return &pgconn.PgError{Code: "40001"}
+		})
+		assert.True(t, pgsql.IsSerializationFailure(err))
+		assert.Equal(t, []int{1, 2}, calls)
+	})
+}
+
+func TestFinalized(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Outside Tx", func(t *testing.T) {
+		ctx, _ := newCtx(t)
+		var result *bool
+		pgctx.Finalized(ctx, func(ctx context.Context, committed bool) {
+			result = &committed
+		})
+		if assert.NotNil(t, result) {
+			assert.True(t, *result)
+		}
+	})
+
+	t.Run("Order", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		var calls []string
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			pgctx.Committed(ctx, func(ctx context.Context) {
+				calls = append(calls, "committed")
+			})
+			pgctx.Finalized(ctx, func(ctx context.Context, committed bool) {
+				calls = append(calls, fmt.Sprintf("finalized %v", committed))
+			})
+			pgctx.RolledBack(ctx, func(ctx context.Context) {
+				calls = append(calls, "rolled back")
+			})
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"committed", "finalized true"}, calls)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		var calls []string
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			pgctx.RolledBack(ctx, func(ctx context.Context) {
+				calls = append(calls, "rolled back")
+			})
+			pgctx.Finalized(ctx, func(ctx context.Context, committed bool) {
+				calls = append(calls, fmt.Sprintf("finalized %v", committed))
+			})
+			return pgsql.ErrAbortTx
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"rolled back", "finalized false"}, calls)
+	})
+
+	t.Run("Savepoint", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		var calls []string
+		mock.ExpectBegin()
+		mock.ExpectExec("savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+		mock.ExpectExec("rollback to savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("ROLLBACK", 0))
+		mock.ExpectExec("savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+		mock.ExpectExec("release savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("RELEASE", 0))
+		mock.ExpectRollback()
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			pgctx.RunInSavepoint(ctx, func(ctx context.Context) error {
+				pgctx.Finalized(ctx, func(ctx context.Context, committed bool) {
+					calls = append(calls, fmt.Sprintf("sp1 %v", committed))
+				})
+				return pgsql.ErrAbortTx
+			})
+			calls = append(calls, "between")
+			pgctx.RunInSavepoint(ctx, func(ctx context.Context) error {
+				pgctx.Finalized(ctx, func(ctx context.Context, committed bool) {
+					calls = append(calls, fmt.Sprintf("sp2 %v", committed))
+				})
+				return nil
+			})
+			return fmt.Errorf("error")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, []string{"sp1 false", "between", "sp2 false"}, calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCommittedRetry(t *testing.T) {
+	t.Parallel()
+
+	ctx, mock := newCtx(t)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	var calls []int
+	err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+		attempt := pgsql.Attempt(ctx)
+		pgctx.Committed(ctx, func(ctx context.Context) {
+			calls = append(calls, attempt)
+		})
+		if attempt < 3 {
+			return &pgconn.PgError{Code: "40001"}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3}, calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBeforeCommit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Outside Tx", func(t *testing.T) {
+		ctx, _ := newCtx(t)
+		retErr := fmt.Errorf("error")
+		err := pgctx.BeforeCommit(ctx, func(ctx context.Context) error {
+			return retErr
+		})
+		assert.Equal(t, retErr, err)
+		assert.NoError(t, pgctx.BeforeCommit(ctx, nil))
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		var calls []string
+		mock.ExpectBegin()
+		mock.ExpectExec("insert into audit").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			pgctx.Committed(ctx, func(ctx context.Context) {
+				calls = append(calls, "committed")
+			})
+			pgctx.BeforeCommit(ctx, func(ctx context.Context) error {
+				calls = append(calls, "before commit")
+				assert.True(t, pgctx.IsInTx(ctx))
+				_, err := pgctx.Exec(ctx, "insert into audit")
+				return err
+			})
+			calls = append(calls, "f")
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"f", "before commit", "committed"}, calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Veto", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		retErr := fmt.Errorf("veto")
+		rolledBack := false
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			pgctx.Committed(ctx, func(ctx context.Context) {
+				assert.Fail(t, "should not be called")
+			})
+			pgctx.RolledBack(ctx, func(ctx context.Context) {
+				rolledBack = true
+			})
+			pgctx.BeforeCommit(ctx, func(ctx context.Context) error {
+				return retErr
+			})
+			return nil
+		})
+		assert.Equal(t, retErr, err)
+		assert.True(t, rolledBack)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Abort Tx", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			pgctx.BeforeCommit(ctx, func(ctx context.Context) error {
+				assert.Fail(t, "should not be called")
+				return nil
+			})
+			return pgsql.ErrAbortTx
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Savepoint Rollback", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+		mock.ExpectExec("rollback to savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("ROLLBACK", 0))
+		mock.ExpectCommit()
+		err := pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			return pgctx.RunInSavepoint(ctx, func(ctx context.Context) error {
+				pgctx.BeforeCommit(ctx, func(ctx context.Context) error {
+					assert.Fail(t, "should not be called")
+					return nil
+				})
+				return pgsql.ErrAbortTx
+			})
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPanicMode(t *testing.T) {
+	t.Parallel()
+
+	opts := &pgsql.TxOptions{PanicMode: pgsql.PanicRecover}
+
+	t.Run("Recover", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		rolledBack := false
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		err := pgctx.RunInTxOptions(ctx, opts, func(ctx context.Context) error {
+			pgctx.RolledBack(ctx, func(ctx context.Context) {
+				rolledBack = true
+			})
+			panic("oops")
+		})
+		var panicErr *pgsql.PanicError
+		if assert.ErrorAs(t, err, &panicErr) {
+			assert.Equal(t, "oops", panicErr.Value)
+		}
+		assert.True(t, rolledBack)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Recover RunTx", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		_, err := pgctx.BeginTxOption(ctx, opts, func(ctx context.Context) (*int, error) {
+			panic("oops")
+		})
+		assert.IsType(t, &pgsql.PanicError{}, err)
+	})
+
+	t.Run("Committed Hook", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		called := false
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		err := pgctx.RunInTxOptions(ctx, opts, func(ctx context.Context) error {
+			pgctx.Committed(ctx, func(ctx context.Context) {
+				panic("oops")
+			})
+			pgctx.Committed(ctx, func(ctx context.Context) {
+				called = true
+			})
+			return nil
+		})
+		assert.IsType(t, &pgsql.PanicError{}, err)
+		assert.True(t, called)
+	})
+
+	t.Run("Repanic Committed Hook", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		func() {
+			defer func() {
+				assert.IsType(t, &pgsql.PanicError{}, recover())
+			}()
+			pgctx.RunInTxOptions(ctx, &pgsql.TxOptions{PanicMode: pgsql.PanicRepanic}, func(ctx context.Context) error {
+				pgctx.Committed(ctx, func(ctx context.Context) {
+					panic("oops")
+				})
+				return nil
+			})
+		}()
+	})
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	t.Run("No DB", func(t *testing.T) {
+		ctx := context.Background()
+
+		_, err := pgctx.LookupDB(ctx)
+		assert.ErrorIs(t, err, pgctx.ErrNoDB)
+		_, err = pgctx.LookupDBKey(ctx, testKey1{})
+		assert.ErrorIs(t, err, pgctx.ErrNoDB)
+		_, err = pgctx.LookupDB(pgctx.With(ctx, testKey1{}))
+		assert.ErrorIs(t, err, pgctx.ErrNoDB)
+
+		assert.NotPanics(t, func() {
+			var x int
+			assert.ErrorIs(t, pgctx.QueryRow(ctx, "select 1").Scan(&x), pgctx.ErrNoDB)
+			_, err := pgctx.Query(ctx, "select 1")
+			assert.ErrorIs(t, err, pgctx.ErrNoDB)
+			_, err = pgctx.Exec(ctx, "select 1")
+			assert.ErrorIs(t, err, pgctx.ErrNoDB)
+			_, err = pgctx.Collect[struct{ X int }](ctx, "select 1")
+			assert.ErrorIs(t, err, pgctx.ErrNoDB)
+			_, err = pgctx.CollectOne[struct{ X int }](ctx, "select 1")
+			assert.ErrorIs(t, err, pgctx.ErrNoDB)
+			assert.ErrorIs(t, pgctx.Iter(ctx, nil, "select 1"), pgctx.ErrNoDB)
+			assert.ErrorIs(t, pgctx.RunInTx(ctx, func(ctx context.Context) error { return nil }), pgctx.ErrNoDB)
+		})
+
+		assert.Panics(t, func() {
+			pgctx.GetDB(ctx)
+		})
+	})
+
+	t.Run("DB", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		db, err := pgctx.LookupDB(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, mock, db)
+
+		ctx = pgctx.NewKeyContext(ctx, testKey1{}, mock)
+		db, err = pgctx.LookupDBKey(ctx, testKey1{})
+		assert.NoError(t, err)
+		assert.Equal(t, mock, db)
+	})
+
+	t.Run("Tx", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		_, err := pgctx.LookupTx(ctx)
+		assert.ErrorIs(t, err, pgctx.ErrNotInTx)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		err = pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			tx, err := pgctx.LookupTx(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, pgctx.GetTx(ctx), tx)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+}
+
+func TestKeyTx(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Independent", func(t *testing.T) {
+		ctx, mock1 := newCtx(t)
+		mock2, err := pgxmock.NewPool()
+		assert.NoError(t, err)
+		ctx = pgctx.NewKeyContext(ctx, testKey1{}, mock2)
+
+		mock1.ExpectBegin()
+		mock1.ExpectExec("insert into a").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock1.ExpectCommit()
+		mock2.ExpectBegin()
+		mock2.ExpectExec("insert into b").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock2.ExpectRollback()
+
+		var calls []string
+		err = pgctx.RunInTx(ctx, func(ctx context.Context) error {
+			assert.True(t, pgctx.IsInTx(ctx))
+			assert.False(t, pgctx.IsInTxKey(ctx, testKey1{}))
+
+			_, err := pgctx.Exec(ctx, "insert into a")
+			assert.NoError(t, err)
+			pgctx.Committed(ctx, func(ctx context.Context) {
+				calls = append(calls, "committed a")
+			})
+
+			err = pgctx.RunInTxKey(ctx, testKey1{}, func(ctx context.Context) error {
+				assert.True(t, pgctx.IsInTx(ctx))
+				assert.True(t, pgctx.IsInTxKey(ctx, testKey1{}))
+				assert.NotEqual(t, pgctx.GetTx(ctx), pgctx.GetTxKey(ctx, testKey1{}))
+
+				_, err := pgctx.ExecKey(ctx, testKey1{}, "insert into b")
+				assert.NoError(t, err)
+				pgctx.CommittedKey(ctx, testKey1{}, func(ctx context.Context) {
+					calls = append(calls, "committed b")
+				})
+				pgctx.RolledBackKey(ctx, testKey1{}, func(ctx context.Context) {
+					calls = append(calls, "rolled back b")
+				})
+				return pgsql.ErrAbortTx
+			})
+			assert.NoError(t, err)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"rolled back b", "committed a"}, calls)
+		assert.NoError(t, mock1.ExpectationsWereMet())
+		assert.NoError(t, mock2.ExpectationsWereMet())
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		ctx, mock1 := newCtx(t)
+		mock2, err := pgxmock.NewPool()
+		assert.NoError(t, err)
+		ctx = pgctx.NewKeyContext(ctx, testKey1{}, mock2)
+
+		mock2.ExpectBegin()
+		mock2.ExpectQuery("select").WillReturnRows(pgxmock.NewRows([]string{"x"}).AddRow(1))
+		mock2.ExpectQuery("select").WillReturnRows(pgxmock.NewRows([]string{"x"}).AddRow(2))
+		mock2.ExpectCommit()
+
+		err = pgctx.RunInTxKey(ctx, testKey1{}, func(ctx context.Context) error {
+			assert.False(t, pgctx.IsInTx(ctx))
+
+			var x int
+			err := pgctx.QueryRowKey(ctx, testKey1{}, "select 1").Scan(&x)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, x)
+
+			// With uses keyed tx
+			err = pgctx.QueryRow(pgctx.With(ctx, testKey1{}), "select 1").Scan(&x)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, x)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock1.ExpectationsWereMet())
+		assert.NoError(t, mock2.ExpectationsWereMet())
+	})
+
+	t.Run("No DB", func(t *testing.T) {
+		ctx, _ := newCtx(t)
+
+		err := pgctx.RunInTxKey(ctx, testKey1{}, func(ctx context.Context) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, pgctx.ErrNoDB)
+		_, err = pgctx.LookupTxKey(ctx, testKey1{})
+		assert.ErrorIs(t, err, pgctx.ErrNotInTx)
+	})
+}
+
+func TestCollectByName(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID       int64
+		Username string `db:"name"`
+	}
+
+	t.Run("Collect", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("select").
+			WillReturnRows(pgxmock.NewRows([]string{"name", "id"}).
+				AddRow("a", int64(1)).
+				AddRow("b", int64(2)))
+
+		xs, err := pgctx.CollectByName[user](ctx, "select name, id from users")
+		assert.NoError(t, err)
+		assert.Equal(t, []*user{{1, "a"}, {2, "b"}}, xs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CollectOne", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("select").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a"))
+
+		x, err := pgctx.CollectOneByName[user](ctx, "select id, name from users")
+		assert.NoError(t, err)
+		assert.Equal(t, &user{1, "a"}, x)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("No rows", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectQuery("select").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}))
+
+		_, err := pgctx.CollectOneByName[user](ctx, "select id, name from users")
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("Mapper", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		m := &pgsql.StructMapper{Strict: true, Naming: strings.ToUpper}
+
+		mock.ExpectQuery("select").
+			WillReturnRows(pgxmock.NewRows([]string{"ID", "name"}).AddRow(int64(1), "a"))
+		mock.ExpectQuery("select").
+			WillReturnRows(pgxmock.NewRows([]string{"ID", "name", "email"}).AddRow(int64(1), "a", "b"))
+
+		xs, err := pgctx.CollectByMapper[user](ctx, m, "select id, name from users")
+		assert.NoError(t, err)
+		assert.Equal(t, []*user{{1, "a"}}, xs)
+
+		_, err = pgctx.CollectOneByMapper[user](ctx, m, "select id, name, email from users")
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Key", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		ctx := pgctx.NewKeyContext(context.Background(), testKey1{}, mock)
+
+		mock.ExpectQuery("select").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a"))
+		mock.ExpectQuery("select").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(int64(2), "b"))
+
+		xs, err := pgctx.CollectByNameKey[user](ctx, testKey1{}, "select id, name from users")
+		assert.NoError(t, err)
+		assert.Equal(t, []*user{{1, "a"}}, xs)
+
+		x, err := pgctx.CollectOneByNameKey[user](ctx, testKey1{}, "select id, name from users")
+		assert.NoError(t, err)
+		assert.Equal(t, &user{2, "b"}, x)
+
+		_, err = pgctx.CollectByName[user](ctx, "select id, name from users")
+		assert.ErrorIs(t, err, pgctx.ErrNoDB)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,256 @@
+package pgctx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/xkamail/pgsql"
+)
+
+// Tracer traces queries that run through pgctx.
+//
+// If Tracer also implements pgsql.TxTracer,
+// it traces transactions that started by pgctx when TxOptions.Tracer is nil.
+type Tracer interface {
+	// TraceQueryStart is called before query starts,
+	// the returned context is used for the query and TraceQueryEnd
+	TraceQueryStart(ctx context.Context, data TraceQueryStartData) context.Context
+
+	// TraceQueryEnd is called after query ended,
+	// for Query it is called when rows closed,
+	// for QueryRow it is called after Scan
+	TraceQueryEnd(ctx context.Context, data TraceQueryEndData)
+}
+
+// TraceQueryStartData is the data passed to Tracer.TraceQueryStart
+type TraceQueryStartData struct {
+	SQL  string
+	Args []any
+	InTx bool
+}
+
+// TraceQueryEndData is the data passed to Tracer.TraceQueryEnd
+type TraceQueryEndData struct {
+	TraceQueryStartData
+	CommandTag pgconn.CommandTag
+	Duration   time.Duration
+	Err        error
+}
+
+// NewTracerContext creates new context with tracer
+func NewTracerContext(ctx context.Context, t Tracer) context.Context {
+	return context.WithValue(ctx, ctxKeyTracer{}, t)
+}
+
+// TracerMiddleware injects tracer into request's context
+func TracerMiddleware(t Tracer) func(h http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(NewTracerContext(r.Context(), t))
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+type ctxKeyTracer struct{}
+
+// MultiTracer creates tracer that calls all tracers in order,
+// the returned tracer also calls tracers that implement pgsql.TxTracer
+func MultiTracer(t ...Tracer) Tracer {
+	return multiTracer(t)
+}
+
+type multiTracer []Tracer
+
+var _ pgsql.TxTracer = multiTracer{}
+
+func (m multiTracer) TraceQueryStart(ctx context.Context, data TraceQueryStartData) context.Context {
+	for _, t := range m {
+		ctx = t.TraceQueryStart(ctx, data)
+	}
+	return ctx
+}
+
+func (m multiTracer) TraceQueryEnd(ctx context.Context, data TraceQueryEndData) {
+	for _, t := range m {
+		t.TraceQueryEnd(ctx, data)
+	}
+}
+
+func (m multiTracer) TraceTxStart(ctx context.Context, data pgsql.TraceTxStartData) context.Context {
+	for _, t := range m {
+		if t, ok := t.(pgsql.TxTracer); ok {
+			ctx = t.TraceTxStart(ctx, data)
+		}
+	}
+	return ctx
+}
+
+func (m multiTracer) TraceTxEnd(ctx context.Context, data pgsql.TraceTxEndData) {
+	for _, t := range m {
+		if t, ok := t.(pgsql.TxTracer); ok {
+			t.TraceTxEnd(ctx, data)
+		}
+	}
+}
+
+// LogTracer is the Tracer that logs queries with slog
+type LogTracer struct {
+	// Logger is the logger, nil will use slog.Default()
+	Logger *slog.Logger
+
+	// Level is the level of success queries
+	Level slog.Level
+
+	// SlowThreshold logs queries that take longer as warning,
+	// zero disables slow query log
+	SlowThreshold time.Duration
+
+	// LogArgs logs query args
+	LogArgs bool
+
+	// Redact returns args to log, nil will log args as is.
+	// Redact must not modify args.
+	Redact func(args []any) []any
+}
+
+var _ Tracer = &LogTracer{}
+
+// TraceQueryStart implements Tracer
+func (t *LogTracer) TraceQueryStart(ctx context.Context, _ TraceQueryStartData) context.Context {
+	return ctx
+}
+
+// TraceQueryEnd implements Tracer
+func (t *LogTracer) TraceQueryEnd(ctx context.Context, data TraceQueryEndData) {
+	l := t.Logger
+	if l == nil {
+		l = slog.Default()
+	}
+
+	level := t.Level
+	msg := "pgctx: query"
+	if t.SlowThreshold > 0 && data.Duration >= t.SlowThreshold {
+		level = slog.LevelWarn
+		msg = "pgctx: slow query"
+	}
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		level = slog.LevelError
+		msg = "pgctx: query error"
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("sql", data.SQL),
+		slog.Duration("duration", data.Duration),
+		slog.Bool("in_tx", data.InTx),
+	}
+	if t.LogArgs {
+		args := data.Args
+		if t.Redact != nil {
+			args = t.Redact(args)
+		}
+		attrs = append(attrs, slog.Any("args", args))
+	}
+	if data.CommandTag.String() != "" {
+		attrs = append(attrs, slog.Int64("rows_affected", data.CommandTag.RowsAffected()))
+	}
+	if data.Err != nil {
+		attrs = append(attrs, slog.Any("error", data.Err))
+	}
+	l.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// traceQueryer traces queries of Queryer
+type traceQueryer struct {
+	Queryer
+	tracer Tracer
+	inTx   bool
+}
+
+func (q *traceQueryer) start(ctx context.Context, sql string, args []any) (context.Context, func(pgconn.CommandTag, error)) {
+	data := TraceQueryStartData{
+		SQL:  sql,
+		Args: args,
+		InTx: q.inTx,
+	}
+	ctx = q.tracer.TraceQueryStart(ctx, data)
+	start := time.Now()
+	var once sync.Once
+	return ctx, func(tag pgconn.CommandTag, err error) {
+		once.Do(func() {
+			q.tracer.TraceQueryEnd(ctx, TraceQueryEndData{
+				TraceQueryStartData: data,
+				CommandTag:          tag,
+				Duration:            time.Since(start),
+				Err:                 err,
+			})
+		})
+	}
+}
+
+func (q *traceQueryer) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	ctx, end := q.start(ctx, sql, args)
+	return &traceRow{
+		Row: q.Queryer.QueryRow(ctx, sql, args...),
+		end: end,
+	}
+}
+
+func (q *traceQueryer) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	ctx, end := q.start(ctx, sql, args)
+	rows, err := q.Queryer.Query(ctx, sql, args...)
+	if err != nil {
+		end(pgconn.CommandTag{}, err)
+		return nil, err
+	}
+	return &traceRows{
+		Rows: rows,
+		end:  end,
+	}, nil
+}
+
+func (q *traceQueryer) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	ctx, end := q.start(ctx, sql, arguments)
+	tag, err := q.Queryer.Exec(ctx, sql, arguments...)
+	end(tag, err)
+	return tag, err
+}
+
+type traceRow struct {
+	pgx.Row
+	end func(pgconn.CommandTag, error)
+}
+
+func (r *traceRow) Scan(dest ...any) error {
+	err := r.Row.Scan(dest...)
+	r.end(pgconn.CommandTag{}, err)
+	return err
+}
+
+type traceRows struct {
+	pgx.Rows
+	end func(pgconn.CommandTag, error)
+}
+
+func (r *traceRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.end(r.Rows.CommandTag(), r.Rows.Err())
+	return false
+}
+
+func (r *traceRows) Close() {
+	r.Rows.Close()
+	r.end(r.Rows.CommandTag(), r.Rows.Err())
+}
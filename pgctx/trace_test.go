@@ -0,0 +1,148 @@
+package pgctx_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xkamail/pgsql/pgctx"
+)
+
+type recordTracer struct {
+	starts []pgctx.TraceQueryStartData
+	ends   []pgctx.TraceQueryEndData
+}
+
+func (t *recordTracer) TraceQueryStart(ctx context.Context, data pgctx.TraceQueryStartData) context.Context {
+	t.starts = append(t.starts, data)
+	return ctx
+}
+
+func (t *recordTracer) TraceQueryEnd(ctx context.Context, data pgctx.TraceQueryEndData) {
+	t.ends = append(t.ends, data)
+}
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	ctx, mock := newCtx(t)
+	var tr recordTracer
+	ctx = pgctx.NewTracerContext(ctx, &tr)
+
+	mock.ExpectExec("update users").
+		WithArgs(1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+	mock.ExpectBegin()
+	mock.ExpectQuery("select id").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectQuery("select name").
+		WillReturnRows(pgxmock.NewRows([]string{"name"}).AddRow("a"))
+	mock.ExpectCommit()
+
+	_, err := pgctx.Exec(ctx, "update users", 1)
+	assert.NoError(t, err)
+
+	err = pgctx.RunInTx(ctx, func(ctx context.Context) error {
+		rows, err := pgctx.Query(ctx, "select id")
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+		}
+		rows.Close()
+
+		var name string
+		return pgctx.QueryRow(ctx, "select name").Scan(&name)
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	if assert.Len(t, tr.starts, 3) && assert.Len(t, tr.ends, 3) {
+		assert.Equal(t, "update users", tr.ends[0].SQL)
+		assert.Equal(t, []any{1}, tr.ends[0].Args)
+		assert.False(t, tr.ends[0].InTx)
+		assert.EqualValues(t, 3, tr.ends[0].CommandTag.RowsAffected())
+
+		assert.Equal(t, "select id", tr.ends[1].SQL)
+		assert.True(t, tr.ends[1].InTx)
+
+		assert.Equal(t, "select name", tr.ends[2].SQL)
+		assert.True(t, tr.ends[2].InTx)
+		assert.NoError(t, tr.ends[2].Err)
+	}
+}
+
+func TestLogTracer(t *testing.T) {
+	t.Parallel()
+
+	newLogger := func(buf *bytes.Buffer) *slog.Logger {
+		return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	t.Run("Query", func(t *testing.T) {
+		var buf bytes.Buffer
+		tr := &pgctx.LogTracer{
+			Logger:  newLogger(&buf),
+			Level:   slog.LevelDebug,
+			LogArgs: true,
+			Redact: func(args []any) []any {
+				return []any{"***"}
+			},
+		}
+		tr.TraceQueryEnd(context.Background(), pgctx.TraceQueryEndData{
+			TraceQueryStartData: pgctx.TraceQueryStartData{SQL: "select 1", Args: []any{"secret"}},
+		})
+		s := buf.String()
+		assert.Contains(t, s, "level=DEBUG")
+		assert.Contains(t, s, `msg="pgctx: query"`)
+		assert.Contains(t, s, `sql="select 1"`)
+		assert.Contains(t, s, "***")
+		assert.NotContains(t, s, "secret")
+	})
+
+	t.Run("Slow", func(t *testing.T) {
+		var buf bytes.Buffer
+		tr := &pgctx.LogTracer{
+			Logger:        newLogger(&buf),
+			SlowThreshold: time.Second,
+		}
+		tr.TraceQueryEnd(context.Background(), pgctx.TraceQueryEndData{
+			TraceQueryStartData: pgctx.TraceQueryStartData{SQL: "select 1", Args: []any{"secret"}},
+			Duration:            2 * time.Second,
+		})
+		s := buf.String()
+		assert.Contains(t, s, "level=WARN")
+		assert.Contains(t, s, `msg="pgctx: slow query"`)
+		assert.NotContains(t, s, "secret")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		var buf bytes.Buffer
+		tr := &pgctx.LogTracer{
+			Logger: newLogger(&buf),
+		}
+		tr.TraceQueryEnd(context.Background(), pgctx.TraceQueryEndData{
+			TraceQueryStartData: pgctx.TraceQueryStartData{SQL: "select 1"},
+			Err:                 assert.AnError,
+		})
+		s := buf.String()
+		assert.Contains(t, s, "level=ERROR")
+		assert.True(t, strings.Contains(s, assert.AnError.Error()))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		var buf bytes.Buffer
+		tr := &pgctx.LogTracer{
+			Logger: slog.New(slog.NewTextHandler(&buf, nil)),
+			Level:  slog.LevelDebug,
+		}
+		tr.TraceQueryEnd(context.Background(), pgctx.TraceQueryEndData{})
+		assert.Empty(t, buf.String())
+	})
+}
@@ -0,0 +1,415 @@
+package pgctx
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/xkamail/pgsql"
+)
+
+// TxOptions is the transaction options
+type TxOptions struct {
+	// TxOptions is used when starting new tx,
+	// set AccessMode to pgx.ReadOnly for read-only tx,
+	// and DeferrableMode to pgx.Deferrable for deferrable tx
+	pgsql.TxOptions
+
+	// Savepoint runs f inside a savepoint when already in tx,
+	// otherwise f joins the outer tx
+	Savepoint bool
+
+	// Committed is registered as Committed hook
+	Committed func(ctx context.Context)
+
+	// RolledBack is registered as RolledBack hook
+	RolledBack func(ctx context.Context)
+}
+
+func (opts *TxOptions) registerHooks(ctx context.Context, key any) {
+	CommittedKey(ctx, key, opts.Committed)
+	RolledBackKey(ctx, key, opts.RolledBack)
+}
+
+func txOptions(opt *pgsql.TxOptions) *TxOptions {
+	var opts TxOptions
+	if opt != nil {
+		opts.TxOptions = *opt
+	}
+	return &opts
+}
+
+// RunTxOptions runs f in a transaction and returns its result.
+//
+// If already in tx, f joins the outer tx,
+// or runs inside a savepoint if opts.Savepoint is set.
+//
+// When opts.PanicMode is set, panic in f and tx hooks are recovered into *pgsql.PanicError.
+func RunTxOptions[R any](ctx context.Context, opts *TxOptions, f func(ctx context.Context) (R, error)) (R, error) {
+	return RunTxOptionsKey(ctx, nil, opts, f)
+}
+
+// RunTxOptionsKey likes RunTxOptions but runs f in a transaction of keyed db.
+//
+// Each key has its own tx state,
+// so transactions of different keys can be active in the same context.
+func RunTxOptionsKey[R any](ctx context.Context, key any, opts *TxOptions, f func(ctx context.Context) (R, error)) (R, error) {
+	if opts == nil {
+		opts = &TxOptions{}
+	}
+
+	if IsInTxKey(ctx, key) {
+		if opts.Savepoint {
+			return runInSavepoint(ctx, key, opts, f)
+		}
+		opts.registerHooks(ctx, key)
+		return f(ctx)
+	}
+	return runInTx(ctx, key, opts, f)
+}
+
+func runInTx[R any](ctx context.Context, key any, opts *TxOptions, f func(ctx context.Context) (R, error)) (result R, err error) {
+	db, err := LookupDBKey(ctx, key)
+	if err != nil {
+		return result, err
+	}
+
+	var (
+		attempts  []*wrapTx
+		abort     bool
+		committed bool
+	)
+	// use defer to also run rolled back hooks when panic
+	defer func() {
+		hookErr := runHooks(ctx, attempts, committed, opts.PanicMode)
+		if err == nil {
+			err = hookErr
+		}
+	}()
+
+	txOpts := opts.TxOptions
+	if txOpts.Tracer == nil {
+		// use tracer from context if it also traces tx
+		txOpts.Tracer, _ = ctx.Value(ctxKeyTracer{}).(pgsql.TxTracer)
+	}
+
+	err = pgsql.RunInTxWithContext(ctx, db, &txOpts, func(ctx context.Context, tx pgx.Tx) error {
+		pTx := &wrapTx{Tx: tx}
+		attempts = append(attempts, pTx)
+		ctx = context.WithValue(ctx, ctxKeyQueryer{key}, pTx)
+		opts.registerHooks(ctx, key)
+
+		r, err := f(ctx)
+		result = r
+		if errors.Is(err, pgsql.ErrAbortTx) {
+			abort = true
+		}
+		if err != nil {
+			return err
+		}
+		return pTx.beforeCommit(ctx)
+	})
+	if err != nil {
+		var zero R
+		return zero, err
+	}
+	committed = !abort
+	return result, nil
+}
+
+func runInSavepoint[R any](ctx context.Context, key any, opts *TxOptions, f func(ctx context.Context) (R, error)) (R, error) {
+	pTx, _ := getTx(ctx, key)
+	sp := wrapTx{
+		Tx:    pTx.Tx,
+		depth: pTx.depth + 1,
+	}
+	name := "pgctx_sp_" + strconv.Itoa(sp.depth)
+
+	var zero R
+	_, err := pTx.Exec(ctx, "savepoint "+name)
+	if err != nil {
+		return zero, pgsql.MapError(err)
+	}
+
+	discarded := false
+	defer func() {
+		// hooks belong to the outer tx when released or panic
+		if !discarded {
+			pTx.merge(&sp)
+		}
+	}()
+
+	spCtx := context.WithValue(ctx, ctxKeyQueryer{key}, &sp)
+	opts.registerHooks(spCtx, key)
+	r, err := f(spCtx)
+	if err != nil {
+		_, rbErr := pTx.Exec(ctx, "rollback to savepoint "+name)
+		discarded = true
+		sp.rolledBack(ctx)
+		if rbErr != nil {
+			return zero, errors.Join(err, pgsql.MapError(rbErr))
+		}
+		if errors.Is(err, pgsql.ErrAbortTx) {
+			return r, nil
+		}
+		return zero, err
+	}
+
+	_, err = pTx.Exec(ctx, "release savepoint "+name)
+	if err != nil {
+		return zero, pgsql.MapError(err)
+	}
+	return r, nil
+}
+
+func RunTx[R any](ctx context.Context, f func(ctx context.Context) (*R, error)) (*R, error) {
+	return RunTxOptions(ctx, nil, f)
+}
+
+// BeginTxOption is a shortcut function that runs f in a transaction.
+// and returns its result.
+//
+// When opt.PanicMode is set, panic in f and tx hooks are recovered into *pgsql.PanicError.
+func BeginTxOption[R any](ctx context.Context, opt *pgsql.TxOptions, f func(ctx context.Context) (*R, error)) (*R, error) {
+	return RunTxOptions(ctx, txOptions(opt), f)
+}
+
+// RunInTxOptions starts sql tx if not started.
+//
+// When opt.PanicMode is set, panic in f and tx hooks are recovered into *pgsql.PanicError.
+func RunInTxOptions(ctx context.Context, opt *pgsql.TxOptions, f func(ctx context.Context) error) error {
+	return runInTxOptions(ctx, nil, txOptions(opt), f)
+}
+
+// RunInTxOptionsKey starts sql tx of keyed db if not started
+func RunInTxOptionsKey(ctx context.Context, key any, opt *pgsql.TxOptions, f func(ctx context.Context) error) error {
+	return runInTxOptions(ctx, key, txOptions(opt), f)
+}
+
+func runInTxOptions(ctx context.Context, key any, opts *TxOptions, f func(ctx context.Context) error) error {
+	_, err := RunTxOptionsKey(ctx, key, opts, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, f(ctx)
+	})
+	return err
+}
+
+// RunInTx calls RunInTxOptions with default options
+func RunInTx(ctx context.Context, f func(ctx context.Context) error) error {
+	return RunInTxOptions(ctx, nil, f)
+}
+
+// RunInTxKey calls RunInTxOptionsKey with default options
+func RunInTxKey(ctx context.Context, key any, f func(ctx context.Context) error) error {
+	return RunInTxOptionsKey(ctx, key, nil, f)
+}
+
+// RunInReadOnlyTx calls RunInTxOptions with read only options
+func RunInReadOnlyTx(ctx context.Context, f func(ctx context.Context) error) error {
+	return RunInReadOnlyTxKey(ctx, nil, f)
+}
+
+// RunInReadOnlyTxKey calls RunInTxOptionsKey with read only options
+func RunInReadOnlyTxKey(ctx context.Context, key any, f func(ctx context.Context) error) error {
+	var opts TxOptions
+	opts.AccessMode = pgx.ReadOnly
+	return runInTxOptions(ctx, key, &opts, f)
+}
+
+// RunInSavepoint runs f inside a savepoint if already in tx,
+// otherwise it starts new tx like RunInTx.
+//
+// When f returns error, only changes made inside f are rolled back
+// and the outer tx can continue.
+// ErrAbortTx rollbacks to the savepoint and returns nil error.
+//
+// Committed hooks registered inside f are kept until the outermost tx committed,
+// or discarded when rollback to the savepoint.
+// RolledBack hooks registered inside f are called after rollback to the savepoint,
+// or kept until the outermost tx rolled back.
+func RunInSavepoint(ctx context.Context, f func(ctx context.Context) error) error {
+	return RunInSavepointKey(ctx, nil, f)
+}
+
+// RunInSavepointKey calls RunInSavepoint on keyed db
+func RunInSavepointKey(ctx context.Context, key any, f func(ctx context.Context) error) error {
+	return runInTxOptions(ctx, key, &TxOptions{Savepoint: true}, f)
+}
+
+// IsInTx checks is context inside RunInTx
+func IsInTx(ctx context.Context) bool {
+	return IsInTxKey(ctx, nil)
+}
+
+// IsInTxKey checks is context inside RunInTxKey of the key
+func IsInTxKey(ctx context.Context, key any) bool {
+	_, ok := getTx(ctx, key)
+	return ok
+}
+
+// Committed calls f after committed or immediate if not in tx.
+//
+// Only hooks registered in the committed attempt are called,
+// hooks from failed attempts are discarded.
+func Committed(ctx context.Context, f func(ctx context.Context)) {
+	CommittedKey(ctx, nil, f)
+}
+
+// CommittedKey likes Committed but registers f to the tx of keyed db
+func CommittedKey(ctx context.Context, key any, f func(ctx context.Context)) {
+	if f == nil {
+		return
+	}
+
+	pTx, ok := getTx(ctx, key)
+	if !ok {
+		f(ctx)
+		return
+	}
+
+	pTx.onCommitted = append(pTx.onCommitted, f)
+}
+
+// BeforeCommit calls f inside tx right before commit,
+// or immediate if not in tx.
+//
+// When f returns error, the tx is rolled back and the error is returned from RunInTx,
+// the tx will be retried if the error is retryable.
+func BeforeCommit(ctx context.Context, f func(ctx context.Context) error) error {
+	return BeforeCommitKey(ctx, nil, f)
+}
+
+// BeforeCommitKey likes BeforeCommit but registers f to the tx of keyed db
+func BeforeCommitKey(ctx context.Context, key any, f func(ctx context.Context) error) error {
+	if f == nil {
+		return nil
+	}
+
+	pTx, ok := getTx(ctx, key)
+	if !ok {
+		return f(ctx)
+	}
+
+	pTx.onBeforeCommit = append(pTx.onBeforeCommit, f)
+	return nil
+}
+
+// RolledBack calls f after rolled back or never if not in tx.
+//
+// Rolled back includes error, ErrAbortTx, panic, and failed attempts that will be retried.
+// Hooks are called in registered order,
+// hooks of failed attempts are called before hooks of the final attempt.
+func RolledBack(ctx context.Context, f func(ctx context.Context)) {
+	RolledBackKey(ctx, nil, f)
+}
+
+// RolledBackKey likes RolledBack but registers f to the tx of keyed db
+func RolledBackKey(ctx context.Context, key any, f func(ctx context.Context)) {
+	if f == nil {
+		return
+	}
+
+	pTx, ok := getTx(ctx, key)
+	if !ok {
+		return
+	}
+
+	pTx.onRolledBack = append(pTx.onRolledBack, f)
+}
+
+// Finalized calls f after committed or rolled back,
+// or immediate with committed = true if not in tx
+//
+// Finalized hooks are called in the same order as Committed and RolledBack hooks.
+func Finalized(ctx context.Context, f func(ctx context.Context, committed bool)) {
+	FinalizedKey(ctx, nil, f)
+}
+
+// FinalizedKey likes Finalized but registers f to the tx of keyed db
+func FinalizedKey(ctx context.Context, key any, f func(ctx context.Context, committed bool)) {
+	if f == nil {
+		return
+	}
+
+	pTx, ok := getTx(ctx, key)
+	if !ok {
+		f(ctx, true)
+		return
+	}
+
+	pTx.onCommitted = append(pTx.onCommitted, func(ctx context.Context) { f(ctx, true) })
+	pTx.onRolledBack = append(pTx.onRolledBack, func(ctx context.Context) { f(ctx, false) })
+}
+
+// wrapTx is the tx of a single attempt,
+// hooks registered in an attempt are discarded when the attempt is retried
+type wrapTx struct {
+	pgx.Tx
+	onBeforeCommit []func(ctx context.Context) error
+	onCommitted    []func(ctx context.Context)
+	onRolledBack   []func(ctx context.Context)
+	depth          int // savepoint depth, 0 is the outermost tx
+}
+
+var _ Queryer = &wrapTx{}
+
+func (t *wrapTx) beforeCommit(ctx context.Context) error {
+	// hook can register more hooks
+	for i := 0; i < len(t.onBeforeCommit); i++ {
+		err := t.onBeforeCommit[i](ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *wrapTx) rolledBack(ctx context.Context) {
+	for _, f := range t.onRolledBack {
+		f(ctx)
+	}
+}
+
+// merge moves hooks from released savepoint into t
+func (t *wrapTx) merge(sp *wrapTx) {
+	t.onBeforeCommit = append(t.onBeforeCommit, sp.onBeforeCommit...)
+	t.onCommitted = append(t.onCommitted, sp.onCommitted...)
+	t.onRolledBack = append(t.onRolledBack, sp.onRolledBack...)
+}
+
+// runHooks runs rolled back hooks of all attempts,
+// except the last attempt which runs committed hooks if committed.
+//
+// Hook panic is recovered into *pgsql.PanicError unless mode is pgsql.PanicPropagate.
+func runHooks(ctx context.Context, attempts []*wrapTx, committed bool, mode pgsql.PanicMode) error {
+	var panicErr error
+	for i, pTx := range attempts {
+		hooks := pTx.onRolledBack
+		if committed && i == len(attempts)-1 {
+			hooks = pTx.onCommitted
+		}
+		for _, f := range hooks {
+			err := callHook(ctx, f, mode)
+			if panicErr == nil {
+				panicErr = err
+			}
+		}
+	}
+	if panicErr != nil && mode == pgsql.PanicRepanic {
+		panic(panicErr)
+	}
+	return panicErr
+}
+
+func callHook(ctx context.Context, f func(ctx context.Context), mode pgsql.PanicMode) (err error) {
+	if mode != pgsql.PanicPropagate {
+		defer func() {
+			if r := recover(); r != nil {
+				err = pgsql.NewPanicError(r)
+			}
+		}()
+	}
+	f(ctx)
+	return nil
+}
@@ -0,0 +1,144 @@
+package pgctx_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xkamail/pgsql"
+	"github.com/xkamail/pgsql/pgctx"
+)
+
+func TestRunTxOptions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Value", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		n, err := pgctx.RunTxOptions(ctx, nil, func(ctx context.Context) (int, error) {
+			return 7, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 7, n)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		n, err := pgctx.RunTxOptions(ctx, nil, func(ctx context.Context) (int, error) {
+			return 7, fmt.Errorf("error")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 0, n)
+	})
+
+	t.Run("Read Only Deferrable", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		txOpts := pgx.TxOptions{
+			IsoLevel:       pgx.Serializable,
+			AccessMode:     pgx.ReadOnly,
+			DeferrableMode: pgx.Deferrable,
+		}
+		mock.ExpectBeginTx(txOpts)
+		mock.ExpectCommit()
+		var opts pgctx.TxOptions
+		opts.TxOptions.TxOptions = txOpts
+		s, err := pgctx.RunTxOptions(ctx, &opts, func(ctx context.Context) (string, error) {
+			return "ok", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", s)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Hooks", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		var calls []string
+		opts := &pgctx.TxOptions{
+			Committed: func(ctx context.Context) {
+				calls = append(calls, "committed")
+			},
+			RolledBack: func(ctx context.Context) {
+				calls = append(calls, "rolled back")
+			},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		_, err := pgctx.RunTxOptions(ctx, opts, func(ctx context.Context) (int, error) {
+			return 1, nil
+		})
+		assert.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		_, err = pgctx.RunTxOptions(ctx, opts, func(ctx context.Context) (int, error) {
+			return 0, pgsql.ErrAbortTx
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"committed", "rolled back"}, calls)
+	})
+
+	t.Run("Join Outer Tx", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		called := false
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		n, err := pgctx.RunTxOptions(ctx, nil, func(ctx context.Context) (int, error) {
+			return pgctx.RunTxOptions(ctx, &pgctx.TxOptions{
+				Committed: func(ctx context.Context) {
+					called = true
+				},
+			}, func(ctx context.Context) (int, error) {
+				assert.False(t, called)
+				return 2, nil
+			})
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.True(t, called)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Savepoint", func(t *testing.T) {
+		ctx, mock := newCtx(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+		mock.ExpectExec("release savepoint pgctx_sp_1").WillReturnResult(pgxmock.NewResult("RELEASE", 0))
+		mock.ExpectCommit()
+		n, err := pgctx.RunTxOptions(ctx, nil, func(ctx context.Context) (int, error) {
+			return pgctx.RunTxOptions(ctx, &pgctx.TxOptions{Savepoint: true}, func(ctx context.Context) (int, error) {
+				return 3, nil
+			})
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRunInReadOnlyTx(t *testing.T) {
+	t.Parallel()
+
+	ctx, mock := newCtx(t)
+
+	mock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	mock.ExpectCommit()
+	err := pgctx.RunInReadOnlyTx(ctx, func(ctx context.Context) error {
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -20,6 +20,14 @@ type Cond interface {
 	LikeRaw(field, rawValue any)
 	ILike(field, value any)
 	ILikeRaw(field, rawValue any)
+	Contains(field, value any)
+	ContainsRaw(field, rawValue any)
+	ContainedBy(field, value any)
+	ContainedByRaw(field, rawValue any)
+	Overlaps(field, value any)
+	OverlapsRaw(field, rawValue any)
+	Adjacent(field, value any)
+	AdjacentRaw(field, rawValue any)
 	In(field any, value ...any)
 	InRaw(field any, value ...any)
 	InSelect(field any, f func(b SelectStatement))
@@ -54,6 +62,10 @@ type CondOp interface {
 	Ge() CondValue
 	Like() CondValue
 	ILike() CondValue
+	Contains() CondValue
+	ContainedBy() CondValue
+	Overlaps() CondValue
+	Adjacent() CondValue
 	In() CondValues
 	NotIn() CondValues
 	IsNull()
@@ -157,6 +169,42 @@ func (st *cond) ILikeRaw(field, rawValue any) {
 	st.OpRaw(field, "ilike", rawValue)
 }
 
+// Contains builds field @> value
+func (st *cond) Contains(field, value any) {
+	st.Op(field, "@>", value)
+}
+
+func (st *cond) ContainsRaw(field, rawValue any) {
+	st.OpRaw(field, "@>", rawValue)
+}
+
+// ContainedBy builds field <@ value
+func (st *cond) ContainedBy(field, value any) {
+	st.Op(field, "<@", value)
+}
+
+func (st *cond) ContainedByRaw(field, rawValue any) {
+	st.OpRaw(field, "<@", rawValue)
+}
+
+// Overlaps builds field && value
+func (st *cond) Overlaps(field, value any) {
+	st.Op(field, "&&", value)
+}
+
+func (st *cond) OverlapsRaw(field, rawValue any) {
+	st.OpRaw(field, "&&", rawValue)
+}
+
+// Adjacent builds field -|- value
+func (st *cond) Adjacent(field, value any) {
+	st.Op(field, "-|-", value)
+}
+
+func (st *cond) AdjacentRaw(field, rawValue any) {
+	st.OpRaw(field, "-|-", rawValue)
+}
+
 func (st *cond) In(field any, value ...any) {
 	var p group
 	for _, v := range value {
@@ -402,6 +450,22 @@ func (op *condOp) ILike() CondValue {
 	return op.Op("ilike")
 }
 
+func (op *condOp) Contains() CondValue {
+	return op.Op("@>")
+}
+
+func (op *condOp) ContainedBy() CondValue {
+	return op.Op("<@")
+}
+
+func (op *condOp) Overlaps() CondValue {
+	return op.Op("&&")
+}
+
+func (op *condOp) Adjacent() CondValue {
+	return op.Op("-|-")
+}
+
 func (op *condOp) In() CondValues {
 	return op.OpValues("in")
 }
@@ -25,7 +25,7 @@ func (r *Result) SQL() (query string, args []any) {
 }
 
 func (r *Result) QueryRow(f func(string, ...any) *sql.Row) *pgsql.Row {
-	return &pgsql.Row{f(r.query, r.args...)}
+	return &pgsql.Row{Row: f(r.query, r.args...)}
 }
 
 func (r *Result) Query(f func(string, ...any) (*sql.Rows, error)) (*pgsql.Rows, error) {
@@ -33,7 +33,7 @@ func (r *Result) Query(f func(string, ...any) (*sql.Rows, error)) (*pgsql.Rows,
 	if err != nil {
 		return nil, err
 	}
-	return &pgsql.Rows{rows}, nil
+	return &pgsql.Rows{Rows: rows}, nil
 }
 
 func (r *Result) Exec(f func(string, ...any) (sql.Result, error)) (sql.Result, error) {
@@ -41,7 +41,7 @@ func (r *Result) Exec(f func(string, ...any) (sql.Result, error)) (sql.Result, e
 }
 
 func (r *Result) QueryRowContext(ctx context.Context, f func(context.Context, string, ...any) *sql.Row) *pgsql.Row {
-	return &pgsql.Row{f(ctx, r.query, r.args...)}
+	return &pgsql.Row{Row: f(ctx, r.query, r.args...)}
 }
 
 func (r *Result) QueryContext(ctx context.Context, f func(context.Context, string, ...any) (*sql.Rows, error)) (*pgsql.Rows, error) {
@@ -49,7 +49,7 @@ func (r *Result) QueryContext(ctx context.Context, f func(context.Context, strin
 	if err != nil {
 		return nil, err
 	}
-	return &pgsql.Rows{rows}, nil
+	return &pgsql.Rows{Rows: rows}, nil
 }
 
 func (r *Result) ExecContext(ctx context.Context, f func(context.Context, string, ...any) (sql.Result, error)) (sql.Result, error) {
@@ -290,6 +290,31 @@ func TestSelect(t *testing.T) {
 				2,
 			},
 		},
+		{
+			"select range operators",
+			pgstmt.Select(func(b pgstmt.SelectStatement) {
+				b.Columns("*")
+				b.From("bookings")
+				b.Where(func(b pgstmt.Cond) {
+					b.Contains("during", "2023-01-01")
+					b.ContainedByRaw("during", "tstzrange(now(), null)")
+					b.Overlaps("during", "[2023-01-01,2023-02-01)")
+					b.Adjacent("during", "[2023-02-01,2023-03-01)")
+					b.Field("tiers").Contains().Value(10)
+					b.Field("tiers").ContainedBy().Raw("int8range(0, 100)")
+					b.Field("tiers").Overlaps().Field("limits")
+					b.Value("[5,10)").Adjacent().Field("tiers")
+				})
+			}),
+			"select * from bookings where (during @> $1 and during <@ tstzrange(now(), null) and during && $2 and during -|- $3 and tiers @> $4 and tiers <@ int8range(0, 100) and tiers && limits and $5 -|- tiers)",
+			[]any{
+				"2023-01-01",
+				"[2023-01-01,2023-02-01)",
+				"[2023-02-01,2023-03-01)",
+				10,
+				"[5,10)",
+			},
+		},
 		{
 			"select and mode",
 			pgstmt.Select(func(b pgstmt.SelectStatement) {
@@ -118,4 +118,15 @@ func TestUpdate(t *testing.T) {
 			args,
 		)
 	})
+
+	t.Run("update where current of", func(t *testing.T) {
+		q, args := pgstmt.Update(func(b pgstmt.UpdateStatement) {
+			b.Table("users")
+			b.Set("name").To("test")
+			b.WhereCurrentOf("MyCursor")
+		}).SQL()
+
+		assert.Equal(t, `update users set name = $1 where current of MyCursor`, q)
+		assert.EqualValues(t, []any{"test"}, args)
+	})
 }
@@ -0,0 +1,146 @@
+// Package pgtrace creates spans for pgctx queries and pgsql transaction attempts.
+//
+// pgtrace does not depend on any tracing library,
+// implement Provider to bridge spans to OpenTelemetry or others.
+package pgtrace
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/xkamail/pgsql"
+	"github.com/xkamail/pgsql/pgctx"
+)
+
+// Provider starts spans
+type Provider interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is the started span
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Attribute is the span attribute
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Attr creates new attribute
+func Attr(key string, value any) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span names
+const (
+	SpanQuery = "pgsql.query"
+	SpanTx    = "pgsql.tx"
+)
+
+// Attribute keys
+const (
+	KeyDBSystem       = "db.system"
+	KeyDBStatement    = "db.statement"
+	KeyRowsAffected   = "db.rows_affected"
+	KeyInTx           = "pgsql.in_tx"
+	KeyAttempt        = "pgsql.tx.attempt"
+	KeyIsolationLevel = "pgsql.tx.isolation_level"
+	KeyAccessMode     = "pgsql.tx.access_mode"
+	KeyOutcome        = "pgsql.tx.outcome"
+)
+
+// DBSystem is the value of db.system attribute
+const DBSystem = "postgresql"
+
+// Tracer creates spans with Provider,
+// it implements pgctx.Tracer and pgsql.TxTracer
+type Tracer struct {
+	Provider Provider
+
+	// OmitStatement omits db.statement attribute
+	OmitStatement bool
+}
+
+var (
+	_ pgctx.Tracer   = &Tracer{}
+	_ pgsql.TxTracer = &Tracer{}
+)
+
+// New creates new tracer
+func New(p Provider) *Tracer {
+	return &Tracer{Provider: p}
+}
+
+type ctxKeySpan struct{}
+
+func (t *Tracer) start(ctx context.Context, name string, attrs ...Attribute) context.Context {
+	attrs = append([]Attribute{Attr(KeyDBSystem, DBSystem)}, attrs...)
+	ctx, span := t.Provider.Start(ctx, name, attrs...)
+	return context.WithValue(ctx, ctxKeySpan{}, span)
+}
+
+func (t *Tracer) end(ctx context.Context, err error, attrs ...Attribute) {
+	span, ok := ctx.Value(ctxKeySpan{}).(Span)
+	if !ok {
+		return
+	}
+	if len(attrs) > 0 {
+		span.SetAttributes(attrs...)
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// TraceQueryStart implements pgctx.Tracer
+func (t *Tracer) TraceQueryStart(ctx context.Context, data pgctx.TraceQueryStartData) context.Context {
+	attrs := []Attribute{Attr(KeyInTx, data.InTx)}
+	if !t.OmitStatement {
+		attrs = append(attrs, Attr(KeyDBStatement, data.SQL))
+	}
+	if attempt := pgsql.Attempt(ctx); attempt > 0 {
+		attrs = append(attrs, Attr(KeyAttempt, attempt))
+	}
+	return t.start(ctx, SpanQuery, attrs...)
+}
+
+// TraceQueryEnd implements pgctx.Tracer
+func (t *Tracer) TraceQueryEnd(ctx context.Context, data pgctx.TraceQueryEndData) {
+	var attrs []Attribute
+	if data.CommandTag.String() != "" {
+		attrs = append(attrs, Attr(KeyRowsAffected, data.CommandTag.RowsAffected()))
+	}
+	err := data.Err
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+	}
+	t.end(ctx, err, attrs...)
+}
+
+// TraceTxStart implements pgsql.TxTracer
+func (t *Tracer) TraceTxStart(ctx context.Context, data pgsql.TraceTxStartData) context.Context {
+	attrs := []Attribute{Attr(KeyAttempt, data.Attempt)}
+	if data.TxOptions.IsoLevel != "" {
+		attrs = append(attrs, Attr(KeyIsolationLevel, string(data.TxOptions.IsoLevel)))
+	}
+	if data.TxOptions.AccessMode != "" {
+		attrs = append(attrs, Attr(KeyAccessMode, string(data.TxOptions.AccessMode)))
+	}
+	return t.start(ctx, SpanTx, attrs...)
+}
+
+// TraceTxEnd implements pgsql.TxTracer
+func (t *Tracer) TraceTxEnd(ctx context.Context, data pgsql.TraceTxEndData) {
+	err := data.Err
+	if data.Outcome == pgsql.TxAbort {
+		err = nil
+	}
+	t.end(ctx, err, Attr(KeyOutcome, string(data.Outcome)))
+}
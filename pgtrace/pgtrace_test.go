@@ -0,0 +1,155 @@
+package pgtrace_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xkamail/pgsql"
+	"github.com/xkamail/pgsql/pgcode"
+	"github.com/xkamail/pgsql/pgctx"
+	"github.com/xkamail/pgsql/pgtrace"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	spans []*span
+}
+
+type span struct {
+	name   string
+	parent *span
+	attrs  map[string]any
+	err    error
+	ended  bool
+}
+
+type ctxKeyParent struct{}
+
+func (r *recorder) Start(ctx context.Context, name string, attrs ...pgtrace.Attribute) (context.Context, pgtrace.Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	parent, _ := ctx.Value(ctxKeyParent{}).(*span)
+	s := &span{name: name, parent: parent, attrs: map[string]any{}}
+	s.SetAttributes(attrs...)
+	r.spans = append(r.spans, s)
+	return context.WithValue(ctx, ctxKeyParent{}, s), s
+}
+
+func (s *span) SetAttributes(attrs ...pgtrace.Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *span) RecordError(err error) {
+	s.err = err
+}
+
+func (s *span) End() {
+	s.ended = true
+}
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+
+	var r recorder
+	ctx := pgctx.NewContext(context.Background(), mock)
+	ctx = pgctx.NewTracerContext(ctx, pgtrace.New(&r))
+
+	mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.Serializable})
+	mock.ExpectExec("update users").WillReturnError(&pgconn.PgError{Code: pgcode.SerializationFailure})
+	mock.ExpectRollback()
+	mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.Serializable})
+	mock.ExpectExec("update users").WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	mock.ExpectCommit()
+
+	var opt pgsql.TxOptions
+	opt.IsoLevel = pgx.Serializable
+	err = pgctx.RunInTxOptions(ctx, &opt, func(ctx context.Context) error {
+		_, err := pgctx.Exec(ctx, "update users")
+		return err
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	if !assert.Len(t, r.spans, 4) {
+		return
+	}
+	for _, s := range r.spans {
+		assert.True(t, s.ended)
+		assert.Equal(t, pgtrace.DBSystem, s.attrs[pgtrace.KeyDBSystem])
+	}
+
+	tx1, q1, tx2, q2 := r.spans[0], r.spans[1], r.spans[2], r.spans[3]
+	assert.Equal(t, pgtrace.SpanTx, tx1.name)
+	assert.Equal(t, 1, tx1.attrs[pgtrace.KeyAttempt])
+	assert.Equal(t, "serializable", tx1.attrs[pgtrace.KeyIsolationLevel])
+	assert.Equal(t, "retry", tx1.attrs[pgtrace.KeyOutcome])
+	assert.Error(t, tx1.err)
+
+	assert.Equal(t, pgtrace.SpanQuery, q1.name)
+	assert.Equal(t, tx1, q1.parent)
+	assert.Equal(t, "update users", q1.attrs[pgtrace.KeyDBStatement])
+	assert.Equal(t, true, q1.attrs[pgtrace.KeyInTx])
+	assert.Equal(t, 1, q1.attrs[pgtrace.KeyAttempt])
+	assert.Error(t, q1.err)
+
+	assert.Equal(t, 2, tx2.attrs[pgtrace.KeyAttempt])
+	assert.Equal(t, "commit", tx2.attrs[pgtrace.KeyOutcome])
+	assert.NoError(t, tx2.err)
+
+	assert.Equal(t, tx2, q2.parent)
+	assert.Equal(t, 2, q2.attrs[pgtrace.KeyAttempt])
+	assert.EqualValues(t, 2, q2.attrs[pgtrace.KeyRowsAffected])
+	assert.NoError(t, q2.err)
+}
+
+func TestTracerOutcome(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		Name    string
+		Err     error
+		Outcome string
+	}{
+		{"Abort", pgsql.ErrAbortTx, "abort"},
+		{"Rollback", assert.AnError, "rollback"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			assert.NoError(t, err)
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+
+			var r recorder
+			err = pgsql.RunInTxContext(context.Background(), mock, &pgsql.TxOptions{Tracer: pgtrace.New(&r)}, func(tx pgx.Tx) error {
+				return tc.Err
+			})
+			if tc.Err == pgsql.ErrAbortTx {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+
+			if assert.Len(t, r.spans, 1) {
+				assert.Equal(t, tc.Outcome, r.spans[0].attrs[pgtrace.KeyOutcome])
+				assert.True(t, r.spans[0].ended)
+			}
+		})
+	}
+}
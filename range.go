@@ -0,0 +1,143 @@
+package pgsql
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Range is the generic range value, likes pgtype.Range but with flags,
+// it scans and encodes with pgx range codecs, ex. int8range, tstzrange.
+//
+// Valid is false when NULL, bound is ignored when it is infinite or range is empty.
+type Range[T any] struct {
+	Lower    T
+	Upper    T
+	LowerInc bool // lower bound is inclusive
+	UpperInc bool // upper bound is inclusive
+	LowerInf bool // lower bound is infinite
+	UpperInf bool // upper bound is infinite
+	Empty    bool
+	Valid    bool
+}
+
+// NewRange returns [lower,upper) range, the postgres canonical form
+func NewRange[T any](lower, upper T) Range[T] {
+	return Range[T]{
+		Lower:    lower,
+		Upper:    upper,
+		LowerInc: true,
+		Valid:    true,
+	}
+}
+
+// EmptyRange returns empty range
+func EmptyRange[T any]() Range[T] {
+	return Range[T]{Empty: true, Valid: true}
+}
+
+// IsNull implements pgtype.RangeValuer
+func (r Range[T]) IsNull() bool {
+	return !r.Valid
+}
+
+// BoundTypes implements pgtype.RangeValuer
+func (r Range[T]) BoundTypes() (lower, upper pgtype.BoundType) {
+	if r.Empty {
+		return pgtype.Empty, pgtype.Empty
+	}
+	return boundType(r.LowerInc, r.LowerInf), boundType(r.UpperInc, r.UpperInf)
+}
+
+func boundType(inc, inf bool) pgtype.BoundType {
+	switch {
+	case inf:
+		return pgtype.Unbounded
+	case inc:
+		return pgtype.Inclusive
+	default:
+		return pgtype.Exclusive
+	}
+}
+
+// Bounds implements pgtype.RangeValuer
+func (r Range[T]) Bounds() (lower, upper any) {
+	return &r.Lower, &r.Upper
+}
+
+// ScanNull implements pgtype.RangeScanner
+func (r *Range[T]) ScanNull() error {
+	*r = Range[T]{}
+	return nil
+}
+
+// ScanBounds implements pgtype.RangeScanner
+func (r *Range[T]) ScanBounds() (lowerTarget, upperTarget any) {
+	return &r.Lower, &r.Upper
+}
+
+// SetBoundTypes implements pgtype.RangeScanner
+func (r *Range[T]) SetBoundTypes(lower, upper pgtype.BoundType) error {
+	var zero T
+	*r = Range[T]{Lower: r.Lower, Upper: r.Upper, Valid: true}
+	if lower == pgtype.Empty || upper == pgtype.Empty {
+		r.Lower, r.Upper = zero, zero
+		r.Empty = true
+		return nil
+	}
+	if lower == pgtype.Unbounded {
+		r.Lower = zero
+		r.LowerInf = true
+	}
+	if upper == pgtype.Unbounded {
+		r.Upper = zero
+		r.UpperInf = true
+	}
+	r.LowerInc = lower == pgtype.Inclusive
+	r.UpperInc = upper == pgtype.Inclusive
+	return nil
+}
+
+// Multirange is the generic multirange value, ex. int8multirange, tstzmultirange,
+// nil is NULL
+type Multirange[T any] []Range[T]
+
+// IsNull implements pgtype.MultirangeGetter
+func (r Multirange[T]) IsNull() bool {
+	return r == nil
+}
+
+// Len implements pgtype.MultirangeGetter
+func (r Multirange[T]) Len() int {
+	return len(r)
+}
+
+// Index implements pgtype.MultirangeGetter
+func (r Multirange[T]) Index(i int) any {
+	return r[i]
+}
+
+// IndexType implements pgtype.MultirangeGetter
+func (r Multirange[T]) IndexType() any {
+	return Range[T]{}
+}
+
+// ScanNull implements pgtype.MultirangeSetter
+func (r *Multirange[T]) ScanNull() error {
+	*r = nil
+	return nil
+}
+
+// SetLen implements pgtype.MultirangeSetter
+func (r *Multirange[T]) SetLen(n int) error {
+	*r = make([]Range[T], n)
+	return nil
+}
+
+// ScanIndex implements pgtype.MultirangeSetter
+func (r Multirange[T]) ScanIndex(i int) any {
+	return &r[i]
+}
+
+// ScanIndexType implements pgtype.MultirangeSetter
+func (r Multirange[T]) ScanIndexType() any {
+	return new(Range[T])
+}
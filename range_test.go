@@ -0,0 +1,111 @@
+package pgsql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xkamail/pgsql"
+)
+
+func TestRange(t *testing.T) {
+	t.Parallel()
+
+	m := pgtype.NewMap()
+
+	cases := []struct {
+		name string
+		r    pgsql.Range[int64]
+		text string
+	}{
+		{"default", pgsql.NewRange[int64](1, 10), "[1,10)"},
+		{"inclusive", pgsql.Range[int64]{Lower: 1, Upper: 10, LowerInc: true, UpperInc: true, Valid: true}, "[1,10]"},
+		{"exclusive", pgsql.Range[int64]{Lower: 1, Upper: 10, Valid: true}, "(1,10)"},
+		{"lower infinite", pgsql.Range[int64]{Upper: 10, LowerInf: true, Valid: true}, "(,10)"},
+		{"upper infinite", pgsql.Range[int64]{Lower: 1, LowerInc: true, UpperInf: true, Valid: true}, "[1,)"},
+		{"empty", pgsql.EmptyRange[int64](), "empty"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := m.Encode(pgtype.Int8rangeOID, pgtype.TextFormatCode, tc.r, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.text, string(b))
+
+			for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+				b, err := m.Encode(pgtype.Int8rangeOID, format, tc.r, nil)
+				require.NoError(t, err)
+
+				r := pgsql.NewRange[int64](99, 99)
+				err = m.Scan(pgtype.Int8rangeOID, format, b, &r)
+				require.NoError(t, err)
+				assert.Equal(t, tc.r, r)
+			}
+		})
+	}
+
+	t.Run("Null", func(t *testing.T) {
+		b, err := m.Encode(pgtype.Int8rangeOID, pgtype.BinaryFormatCode, pgsql.Range[int64]{}, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, b)
+
+		r := pgsql.NewRange[int64](1, 2)
+		assert.NoError(t, m.Scan(pgtype.Int8rangeOID, pgtype.BinaryFormatCode, nil, &r))
+		assert.Equal(t, pgsql.Range[int64]{}, r)
+	})
+}
+
+func TestMultirange(t *testing.T) {
+	t.Parallel()
+
+	m := pgtype.NewMap()
+	at := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	mr := pgsql.Multirange[time.Time]{
+		pgsql.NewRange(at, at.Add(time.Hour)),
+		{Lower: at.Add(2 * time.Hour), LowerInc: true, UpperInf: true, Valid: true},
+	}
+
+	for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+		b, err := m.Encode(pgtype.TstzmultirangeOID, format, mr, nil)
+		require.NoError(t, err)
+
+		var r pgsql.Multirange[time.Time]
+		err = m.Scan(pgtype.TstzmultirangeOID, format, b, &r)
+		require.NoError(t, err)
+		require.Len(t, r, 2)
+		assert.True(t, r[0].Lower.Equal(at))
+		assert.True(t, r[0].Upper.Equal(at.Add(time.Hour)))
+		assert.True(t, r[0].LowerInc)
+		assert.False(t, r[0].UpperInc)
+		assert.True(t, r[1].Lower.Equal(at.Add(2*time.Hour)))
+		assert.True(t, r[1].UpperInf)
+	}
+
+	var r pgsql.Multirange[time.Time]
+	assert.NoError(t, m.Scan(pgtype.TstzmultirangeOID, pgtype.TextFormatCode, []byte("{}"), &r))
+	assert.NotNil(t, r)
+	assert.Empty(t, r)
+}
+
+func TestRange_Collect(t *testing.T) {
+	t.Parallel()
+
+	type tier struct {
+		ID    int64
+		Range pgsql.Range[int64]
+	}
+
+	rows := newTestRows([]string{"id", "range"}, []uint32{pgtype.Int8OID, pgtype.Int8rangeOID},
+		[]*string{str("1"), str("[0,100)")},
+		[]*string{str("2"), str("[100,)")},
+	)
+	xs, err := pgx.CollectRows(rows, pgsql.RowToAddrOfStructByName[tier](nil))
+	require.NoError(t, err)
+	assert.Equal(t, []*tier{
+		{1, pgsql.NewRange[int64](0, 100)},
+		{2, pgsql.Range[int64]{Lower: 100, LowerInc: true, UpperInf: true, Valid: true}},
+	}, xs)
+}
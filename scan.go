@@ -2,38 +2,43 @@ package pgsql
 
 import (
 	"database/sql"
+	"fmt"
 	"reflect"
+	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/lib/pq"
 )
 
-// Scan wraps scanner with custom scanner
+// Scan wraps database/sql scanner with custom scanner,
+// it wraps slice with pq.Array, nested slice with pgx array codec, struct and map with JSON,
+// and pointer to those types with null-able scanner.
+//
+// Use ScanPgx for pgx.Rows.
 func Scan(scan Scanner) Scanner {
 	return func(dest ...any) error {
 		for i, d := range dest {
-			// skip known type
-			switch d.(type) {
-			case sql.Scanner,
-				*time.Time,
-				*[]byte,
-				*int, *int8, *int16, *int32, *int64,
-				*uint, *uint8, *uint16, *uint32, *uint64,
-				*bool,
-				*float32, *float64,
-				*sql.RawBytes,
-				*sql.Rows:
+			dest[i] = sqlScanTarget(d)
+		}
+		return scan(dest...)
+	}
+}
+
+// ScanPgx wraps pgx scanner with custom scanner,
+// slice, nested slice, map, and pointer to those types are scanned by pgx native codecs,
+// plain struct (no methods) is scanned with JSON which ignores null.
+func ScanPgx(scan Scanner) Scanner {
+	return func(dest ...any) error {
+		for i, d := range dest {
+			if isKnownScanTarget(d) {
 				continue
 			}
-
 			dt := reflect.TypeOf(d)
-			if dt.Kind() == reflect.Ptr {
-				dt = dt.Elem()
+			if dt == nil || dt.Kind() != reflect.Pointer {
+				continue
 			}
-			switch dt.Kind() {
-			case reflect.Slice:
-				dest[i] = pq.Array(d)
-			case reflect.Struct:
+			if dt.Elem().Kind() == reflect.Struct && dt.NumMethod() == 0 {
 				dest[i] = JSON(d)
 			}
 		}
@@ -41,6 +46,114 @@ func Scan(scan Scanner) Scanner {
 	}
 }
 
+func isKnownScanTarget(d any) bool {
+	switch d.(type) {
+	case sql.Scanner,
+		nullWrapper,
+		*time.Time,
+		*[]byte,
+		*string,
+		*int, *int8, *int16, *int32, *int64,
+		*uint, *uint8, *uint16, *uint32, *uint64,
+		*bool,
+		*float32, *float64,
+		*sql.RawBytes,
+		*sql.Rows:
+		return true
+	}
+	return false
+}
+
+func sqlScanTarget(d any) any {
+	if isKnownScanTarget(d) {
+		return d
+	}
+
+	dt := reflect.TypeOf(d)
+	if dt == nil || dt.Kind() != reflect.Pointer {
+		return d
+	}
+	switch dt.Elem().Kind() {
+	case reflect.Slice:
+		if isNestedSlice(dt.Elem()) {
+			return &sqlNestedArrayScanner{d}
+		}
+		return pq.Array(d)
+	case reflect.Struct, reflect.Map:
+		return JSON(d)
+	case reflect.Pointer:
+		// database/sql handles pointer to basic types
+		if _, ok := sqlScanTarget(reflect.New(dt.Elem().Elem()).Interface()).(sql.Scanner); ok {
+			return &sqlPtrScanner{reflect.ValueOf(d)}
+		}
+	}
+	return d
+}
+
+// sqlPtrScanner scans null into nil pointer,
+// and allocates new value for non-null
+type sqlPtrScanner struct {
+	ptr reflect.Value // **T
+}
+
+func (s *sqlPtrScanner) Scan(src any) error {
+	p := s.ptr.Elem()
+	if src == nil {
+		p.Set(reflect.Zero(p.Type()))
+		return nil
+	}
+
+	v := reflect.New(p.Type().Elem())
+	err := sqlScanTarget(v.Interface()).(sql.Scanner).Scan(src)
+	if err != nil {
+		return err
+	}
+	p.Set(v)
+	return nil
+}
+
+func isNestedSlice(t reflect.Type) bool {
+	e := t.Elem()
+	return e.Kind() == reflect.Slice && e.Elem().Kind() != reflect.Uint8
+}
+
+// textMaps pools pgtype.Map since it is not safe for concurrent use
+var textMaps = sync.Pool{
+	New: func() any { return pgtype.NewMap() },
+}
+
+// sqlNestedArrayScanner scans multidimensional array text using pgx array codec,
+// since pq.Array does not support multidimensional array
+type sqlNestedArrayScanner struct {
+	dest any // *[][]T
+}
+
+func (s *sqlNestedArrayScanner) Scan(src any) error {
+	t := reflect.TypeOf(s.dest).Elem()
+	for isNestedSlice(t) {
+		t = t.Elem()
+	}
+	m := textMaps.Get().(*pgtype.Map)
+	defer textMaps.Put(m)
+
+	typ, ok := m.TypeForValue(reflect.New(t).Interface())
+	if !ok {
+		return fmt.Errorf("pgsql: array type not found for %s", t)
+	}
+
+	var b []byte
+	switch p := src.(type) {
+	case nil:
+	case []byte:
+		b = p
+	case string:
+		b = []byte(p)
+	default:
+		return fmt.Errorf("pgsql: array not support scan source")
+	}
+	return m.Scan(typ.OID, pgtype.TextFormatCode, b, s.dest)
+}
+
 type Row struct {
 	*sql.Row
 }
@@ -1,9 +1,16 @@
 package pgsql_test
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/xkamail/pgsql"
 )
@@ -11,7 +18,7 @@ import (
 func TestScan(t *testing.T) {
 	db := open(t)
 
-	_, err := db.Exec(`
+	_, err := db.Exec(context.Background(), `
 		drop table if exists test_pgsql_scan;
 		create table test_pgsql_scan (
 			id int primary key,
@@ -23,7 +30,7 @@ func TestScan(t *testing.T) {
 	if !assert.NoError(t, err) {
 		return
 	}
-	defer db.Exec(`drop table test_pgsql_scan`)
+	defer db.Exec(context.Background(), `drop table test_pgsql_scan`)
 
 	var obj struct {
 		A string
@@ -31,7 +38,7 @@ func TestScan(t *testing.T) {
 	}
 	var arr []int64
 
-	err = pgsql.Scan(db.QueryRow(`
+	err = pgsql.Scan(db.QueryRow(context.Background(), `
 		select json_value, array_value
 		from test_pgsql_scan
 		where id = 1
@@ -44,3 +51,114 @@ func TestScan(t *testing.T) {
 	assert.Equal(t, 7, obj.B)
 	assert.Equal(t, []int64{1, 2, 3}, arr)
 }
+
+func TestScanPgx(t *testing.T) {
+	t.Parallel()
+
+	type obj struct {
+		A string
+		B int
+	}
+
+	columns := []string{"json_value", "jsonb_map", "array_value", "nested_array", "nullable_array", "null_json"}
+	oids := []uint32{pgtype.JSONOID, pgtype.JSONBOID, pgtype.Int8ArrayOID, pgtype.Int8ArrayOID, pgtype.Int8ArrayOID, pgtype.JSONOID}
+	rows := newTestRows(columns, oids,
+		[]*string{str(`{"a": "test", "b": 7}`), str(`{"k": 1}`), str("{1,2,3}"), str("{{1,2},{3,4}}"), nil, nil},
+	)
+
+	var (
+		o      obj
+		m      map[string]int
+		arr    []int64
+		nested [][]int64
+		ptrArr = &[]int64{9}
+		null   = obj{A: "keep"}
+	)
+	err := pgsql.IterContext(context.Background(), rowsQueryer{rows}, func(scan pgsql.Scanner) error {
+		return scan(&o, &m, &arr, &nested, &ptrArr, &null)
+	}, "select")
+	require.NoError(t, err)
+
+	assert.Equal(t, obj{"test", 7}, o)
+	assert.Equal(t, map[string]int{"k": 1}, m)
+	assert.Equal(t, []int64{1, 2, 3}, arr)
+	assert.Equal(t, [][]int64{{1, 2}, {3, 4}}, nested)
+	assert.Nil(t, ptrArr)
+	assert.Equal(t, obj{A: "keep"}, null)
+}
+
+// rowsQueryer returns rows from Query
+type rowsQueryer struct {
+	rows pgx.Rows
+}
+
+func (q rowsQueryer) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return q.rows, nil
+}
+
+// sqlScan emulates database/sql scan
+func sqlScan(src ...any) pgsql.Scanner {
+	return func(dest ...any) error {
+		for i, d := range dest {
+			s, ok := d.(sql.Scanner)
+			if !ok {
+				return fmt.Errorf("%T is not sql.Scanner", d)
+			}
+			err := s.Scan(src[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestScanSQL(t *testing.T) {
+	t.Parallel()
+
+	var (
+		m      map[string]int
+		nested [][]int64
+		ptrArr *[]int64
+		ptrObj = &struct{ A string }{A: "a"}
+	)
+	err := pgsql.Scan(sqlScan(
+		[]byte(`{"k": 1}`),
+		[]byte("{{1,2},{3,4}}"),
+		[]byte("{1,2}"),
+		nil,
+	))(&m, &nested, &ptrArr, &ptrObj)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"k": 1}, m)
+	assert.Equal(t, [][]int64{{1, 2}, {3, 4}}, nested)
+	if assert.NotNil(t, ptrArr) {
+		assert.Equal(t, []int64{1, 2}, *ptrArr)
+	}
+	assert.Nil(t, ptrObj)
+}
+
+func TestScanSQL_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	// results are checked after wait, t methods synchronize goroutines and hide data race
+	const n = 8
+	var (
+		wg   sync.WaitGroup
+		errs [n]error
+		xs   [n][][]int64
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = pgsql.Scan(sqlScan([]byte("{{1,2},{3,4}}")))(&xs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, [][]int64{{1, 2}, {3, 4}}, xs[i])
+	}
+}
@@ -0,0 +1,91 @@
+package pgsql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TxTracer traces transaction attempts of RunInTxContext
+type TxTracer interface {
+	// TraceTxStart is called before the attempt begins tx,
+	// the returned context is used for the attempt and TraceTxEnd
+	TraceTxStart(ctx context.Context, data TraceTxStartData) context.Context
+
+	// TraceTxEnd is called after the attempt ended
+	TraceTxEnd(ctx context.Context, data TraceTxEndData)
+}
+
+// TraceTxStartData is the data passed to TxTracer.TraceTxStart
+type TraceTxStartData struct {
+	Attempt   int
+	TxOptions pgx.TxOptions
+}
+
+// TraceTxEndData is the data passed to TxTracer.TraceTxEnd
+type TraceTxEndData struct {
+	TraceTxStartData
+	Outcome TxOutcome
+	Err     error
+}
+
+// TxOutcome is the outcome of a transaction attempt
+type TxOutcome string
+
+const (
+	// TxCommit is the attempt that committed
+	TxCommit TxOutcome = "commit"
+
+	// TxRollback is the attempt that rolled back and will not retry
+	TxRollback TxOutcome = "rollback"
+
+	// TxAbort is the attempt that rolled back by ErrAbortTx
+	TxAbort TxOutcome = "abort"
+
+	// TxRetry is the attempt that rolled back and will retry
+	TxRetry TxOutcome = "retry"
+)
+
+// runAttempt runs f as the attempt and traces it with opts.Tracer
+func (opts *TxOptions) runAttempt(ctx context.Context, attempt int, f func(ctx context.Context) error) (err error) {
+	if opts.Tracer == nil {
+		return f(ctx)
+	}
+
+	data := TraceTxStartData{
+		Attempt:   attempt,
+		TxOptions: opts.TxOptions,
+	}
+	ctx = opts.Tracer.TraceTxStart(ctx, data)
+
+	// rollback when panic
+	outcome := TxRollback
+	defer func() {
+		opts.Tracer.TraceTxEnd(ctx, TraceTxEndData{
+			TraceTxStartData: data,
+			Outcome:          outcome,
+			Err:              err,
+		})
+	}()
+
+	err = f(ctx)
+	outcome = opts.outcome(attempt, err)
+	return err
+}
+
+func (opts *TxOptions) outcome(attempt int, err error) TxOutcome {
+	var panicErr *PanicError
+	switch {
+	case err == nil:
+		return TxCommit
+	case errors.Is(err, ErrAbortTx):
+		return TxAbort
+	case errors.As(err, &panicErr):
+		return TxRollback
+	case attempt < opts.MaxAttempts && opts.RetryPolicy(err):
+		return TxRetry
+	default:
+		return TxRollback
+	}
+}
@@ -3,9 +3,12 @@ package pgsql
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/xkamail/pgsql/pgcode"
 )
 
 // ErrAbortTx rollbacks transaction and return nil error
@@ -20,6 +23,55 @@ type BeginTxer interface {
 type TxOptions struct {
 	pgx.TxOptions
 	MaxAttempts int
+
+	// RetryPolicy decides is failed attempt retryable,
+	// nil will use DefaultRetryPolicy
+	RetryPolicy RetryPolicy
+
+	// Backoff returns delay between attempts,
+	// nil will retry immediately
+	Backoff Backoff
+
+	// PanicMode controls how panic inside fn is handled,
+	// panic is never retried
+	PanicMode PanicMode
+
+	// Tracer traces each attempt, nil disables tracing
+	Tracer TxTracer
+}
+
+// RetryPolicy reports whether transaction should be retried after err
+type RetryPolicy func(err error) bool
+
+// DefaultRetryPolicy retries serialization_failure and deadlock_detected
+func DefaultRetryPolicy(err error) bool {
+	return IsSerializationFailure(err) || IsDeadlock(err)
+}
+
+// RetryCodes creates retry policy that retries only given error codes
+func RetryCodes(code ...string) RetryPolicy {
+	return func(err error) bool {
+		for _, c := range code {
+			if IsErrorCode(err, c) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RetryTransient extends policy to also retry connection-level transient errors,
+// which are connection_exception class (08) errors,
+// and errors that pgconn guarantees no data was sent to the server.
+//
+// nil policy will use DefaultRetryPolicy
+func RetryTransient(policy RetryPolicy) RetryPolicy {
+	if policy == nil {
+		policy = DefaultRetryPolicy
+	}
+	return func(err error) bool {
+		return policy(err) || IsErrorClass(err, pgcode.ClassConnectionException) || pgconn.SafeToRetry(err)
+	}
 }
 
 const (
@@ -36,25 +88,43 @@ func RunInTx(db BeginTxer, opts *TxOptions, fn func(pgx.Tx) error) error {
 // RunInTxContext runs fn inside retryable transaction with context.
 // It use Serializable isolation level if tx options isolation is setted to sql.LevelDefault.
 //
-// RunInTxContext DO NOT handle panic.
+// RunInTxContext DO NOT handle panic by default.
 // But when panic, it will rollback the transaction.
+// Set TxOptions.PanicMode to recover panic into *PanicError.
+//
+// The returned error is mapped with MapError.
 func RunInTxContext(ctx context.Context, db BeginTxer, opts *TxOptions, fn func(pgx.Tx) error) error {
+	return RunInTxWithContext(ctx, db, opts, func(_ context.Context, tx pgx.Tx) error {
+		return fn(tx)
+	})
+}
+
+// RunInTxWithContext likes RunInTxContext but passes attempt's context to fn,
+// use Attempt to get the current attempt number.
+func RunInTxWithContext(ctx context.Context, db BeginTxer, opts *TxOptions, fn func(ctx context.Context, tx pgx.Tx) error) error {
 	option := TxOptions{
 		TxOptions:   pgx.TxOptions{},
 		MaxAttempts: defaultMaxAttempts,
+		RetryPolicy: DefaultRetryPolicy,
 	}
 
 	if opts != nil {
 		if opts.MaxAttempts > 0 {
 			option.MaxAttempts = opts.MaxAttempts
 		}
+		if opts.RetryPolicy != nil {
+			option.RetryPolicy = opts.RetryPolicy
+		}
+		option.Backoff = opts.Backoff
+		option.PanicMode = opts.PanicMode
+		option.Tracer = opts.Tracer
 		// default isolation level is pgx.ReadCommitted
 		// which is empty string
 		option.TxOptions = opts.TxOptions
 
 	}
 
-	f := func() error {
+	f := func(ctx context.Context) (err error) {
 		tx, err := db.BeginTx(ctx, option.TxOptions)
 		if err != nil {
 			return err
@@ -62,24 +132,65 @@ func RunInTxContext(ctx context.Context, db BeginTxer, opts *TxOptions, fn func(
 		// use defer to also rollback when panic
 		defer tx.Rollback(ctx)
 
-		err = fn(tx)
+		if option.PanicMode != PanicPropagate {
+			defer func() {
+				if r := recover(); r != nil {
+					err = NewPanicError(r)
+				}
+			}()
+		}
+
+		err = fn(ctx, tx)
 		if err != nil {
 			return err
 		}
 		return tx.Commit(ctx)
 	}
 
-	var err error
-	for i := 0; i < option.MaxAttempts; i++ {
-		err = f()
+	var (
+		err   error
+		delay time.Duration
+	)
+	for i := 1; i <= option.MaxAttempts; i++ {
+		err = option.runAttempt(context.WithValue(ctx, ctxKeyAttempt{}, i), i, f)
 		if err == nil || errors.Is(err, ErrAbortTx) {
 			return nil
 		}
-		var pqErr *pq.Error
-		if retryable := errors.As(err, &pqErr) && (pqErr.Code == "40001"); !retryable {
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) {
+			if option.PanicMode == PanicRepanic {
+				panic(panicErr)
+			}
 			return err
 		}
+		if !option.RetryPolicy(err) {
+			return MapError(err)
+		}
+		if i == option.MaxAttempts || option.Backoff == nil {
+			continue
+		}
+
+		delay = option.Backoff(i, delay)
+		if delay <= 0 {
+			continue
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
 	}
 
-	return err
+	return MapError(err)
+}
+
+type ctxKeyAttempt struct{}
+
+// Attempt returns the current transaction attempt number starting from 1,
+// or 0 if ctx is not from RunInTxWithContext
+func Attempt(ctx context.Context) int {
+	i, _ := ctx.Value(ctxKeyAttempt{}).(int)
+	return i
 }
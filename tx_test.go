@@ -10,6 +10,11 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/xkamail/pgsql"
 )
@@ -41,6 +46,7 @@ func TestTx(t *testing.T) {
 			IsoLevel: pgx.Serializable,
 		},
 		MaxAttempts: 10,
+		Backoff:     pgsql.DecorrelatedJitterBackoff(time.Millisecond, 50*time.Millisecond),
 	}
 
 	deposit := func(balance int) error {
@@ -158,3 +164,327 @@ func TestTx(t *testing.T) {
 		t.Fatalf("expected sum all value to be 0; got %d", result)
 	}
 }
+
+func TestRunInTxRetry(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Deadlock", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		attempts := 0
+		err = pgsql.RunInTx(mock, nil, func(tx pgx.Tx) error {
+			attempts++
+			if attempts == 1 {
+				return fmt.Errorf("update: %w", &pgconn.PgError{Code: "40P01"})
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("Not Retryable", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		attempts := 0
+		err = pgsql.RunInTx(mock, nil, func(tx pgx.Tx) error {
+			attempts++
+			return &pgconn.PgError{Code: "23505"}
+		})
+		assert.True(t, pgsql.IsUniqueViolation(err))
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("Max Attempts", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+		}
+
+		attempts := 0
+		err = pgsql.RunInTx(mock, &pgsql.TxOptions{MaxAttempts: 3}, func(tx pgx.Tx) error {
+			attempts++
+			return &pgconn.PgError{Code: "40001"}
+		})
+		assert.True(t, pgsql.IsSerializationFailure(err))
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("Custom Policy", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		attempts := 0
+		opts := &pgsql.TxOptions{
+			RetryPolicy: pgsql.RetryCodes("55P03"),
+		}
+		err = pgsql.RunInTx(mock, opts, func(tx pgx.Tx) error {
+			attempts++
+			if attempts == 1 {
+				return &pgconn.PgError{Code: "55P03"}
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, pgsql.DefaultRetryPolicy(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, pgsql.DefaultRetryPolicy(&pq.Error{Code: "40001"}))
+	assert.True(t, pgsql.DefaultRetryPolicy(&pgconn.PgError{Code: "40P01"}))
+	assert.False(t, pgsql.DefaultRetryPolicy(&pgconn.PgError{Code: "08006"}))
+	assert.False(t, pgsql.DefaultRetryPolicy(fmt.Errorf("error")))
+
+	p := pgsql.RetryTransient(nil)
+	assert.True(t, p(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, p(&pgconn.PgError{Code: "08006"}))
+	assert.False(t, p(&pgconn.PgError{Code: "23505"}))
+}
+
+func TestRunInTxBackoff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Attempt", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+		}
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		var attempts []int
+		var delays []time.Duration
+		opts := &pgsql.TxOptions{
+			Backoff: func(attempt int, prev time.Duration) time.Duration {
+				delays = append(delays, prev)
+				return time.Millisecond
+			},
+		}
+		err = pgsql.RunInTxWithContext(context.Background(), mock, opts, func(ctx context.Context, tx pgx.Tx) error {
+			attempts = append(attempts, pgsql.Attempt(ctx))
+			if len(attempts) < 3 {
+				return &pgconn.PgError{Code: "40001"}
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 3}, attempts)
+		assert.Equal(t, []time.Duration{0, time.Millisecond}, delays)
+	})
+
+	t.Run("Context Canceled", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		opts := &pgsql.TxOptions{
+			Backoff: pgsql.ConstantBackoff(time.Hour),
+		}
+		attempts := 0
+		err = pgsql.RunInTxContext(ctx, mock, opts, func(tx pgx.Tx) error {
+			attempts++
+			cancel()
+			return &pgconn.PgError{Code: "40001"}
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, attempts)
+	})
+
+	assert.Equal(t, 0, pgsql.Attempt(context.Background()))
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Constant", func(t *testing.T) {
+		b := pgsql.ConstantBackoff(time.Second)
+		assert.Equal(t, time.Second, b(1, 0))
+		assert.Equal(t, time.Second, b(5, time.Second))
+	})
+
+	t.Run("Exponential", func(t *testing.T) {
+		b := pgsql.ExponentialBackoff(10*time.Millisecond, time.Second)
+		assert.Equal(t, 10*time.Millisecond, b(1, 0))
+		assert.Equal(t, 20*time.Millisecond, b(2, 0))
+		assert.Equal(t, 80*time.Millisecond, b(4, 0))
+		assert.Equal(t, time.Second, b(100, 0))
+	})
+
+	t.Run("Decorrelated Jitter", func(t *testing.T) {
+		b := pgsql.DecorrelatedJitterBackoff(10*time.Millisecond, time.Second)
+		var d time.Duration
+		for i := 1; i <= 100; i++ {
+			prev := d
+			d = b(i, prev)
+			assert.GreaterOrEqual(t, d, 10*time.Millisecond)
+			assert.LessOrEqual(t, d, time.Second)
+			if prev > 0 {
+				assert.LessOrEqual(t, d, 3*prev)
+			}
+		}
+	})
+}
+
+func TestRunInTxPanic(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Propagate", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		assert.PanicsWithValue(t, "oops", func() {
+			pgsql.RunInTx(mock, nil, func(tx pgx.Tx) error {
+				panic("oops")
+			})
+		})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Recover", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		attempts := 0
+		opts := &pgsql.TxOptions{PanicMode: pgsql.PanicRecover}
+		panicValue := fmt.Errorf("oops")
+		err = pgsql.RunInTx(mock, opts, func(tx pgx.Tx) error {
+			attempts++
+			panic(panicValue)
+		})
+
+		var panicErr *pgsql.PanicError
+		if assert.ErrorAs(t, err, &panicErr) {
+			assert.Equal(t, panicValue, panicErr.Value)
+			assert.Contains(t, string(panicErr.Stack), "tx_test.go")
+		}
+		assert.ErrorIs(t, err, panicValue)
+		assert.Equal(t, 1, attempts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Repanic", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		opts := &pgsql.TxOptions{PanicMode: pgsql.PanicRepanic}
+		func() {
+			defer func() {
+				r := recover()
+				if assert.IsType(t, &pgsql.PanicError{}, r) {
+					assert.Equal(t, "oops", r.(*pgsql.PanicError).Value)
+				}
+			}()
+			pgsql.RunInTx(mock, opts, func(tx pgx.Tx) error {
+				panic("oops")
+			})
+		}()
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+type recordTxTracer struct {
+	ends []pgsql.TraceTxEndData
+}
+
+func (t *recordTxTracer) TraceTxStart(ctx context.Context, data pgsql.TraceTxStartData) context.Context {
+	return ctx
+}
+
+func (t *recordTxTracer) TraceTxEnd(ctx context.Context, data pgsql.TraceTxEndData) {
+	t.ends = append(t.ends, data)
+}
+
+func TestRunInTxTracer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Retry", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+		}
+		var tr recordTxTracer
+		opts := &pgsql.TxOptions{MaxAttempts: 2, Tracer: &tr}
+		err = pgsql.RunInTx(mock, opts, func(tx pgx.Tx) error {
+			return &pgconn.PgError{Code: "40001"}
+		})
+		assert.True(t, pgsql.IsSerializationFailure(err))
+
+		if assert.Len(t, tr.ends, 2) {
+			assert.Equal(t, 1, tr.ends[0].Attempt)
+			assert.Equal(t, pgsql.TxRetry, tr.ends[0].Outcome)
+			assert.Equal(t, 2, tr.ends[1].Attempt)
+			assert.Equal(t, pgsql.TxRollback, tr.ends[1].Outcome)
+			assert.Error(t, tr.ends[1].Err)
+		}
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		var tr recordTxTracer
+		err = pgsql.RunInTx(mock, &pgsql.TxOptions{Tracer: &tr}, func(tx pgx.Tx) error {
+			return nil
+		})
+		assert.NoError(t, err)
+		if assert.Len(t, tr.ends, 1) {
+			assert.Equal(t, pgsql.TxCommit, tr.ends[0].Outcome)
+		}
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		var tr recordTxTracer
+		assert.Panics(t, func() {
+			pgsql.RunInTx(mock, &pgsql.TxOptions{Tracer: &tr}, func(tx pgx.Tx) error {
+				panic("oops")
+			})
+		})
+		if assert.Len(t, tr.ends, 1) {
+			assert.Equal(t, pgsql.TxRollback, tr.ends[0].Outcome)
+		}
+	})
+}